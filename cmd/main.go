@@ -1,13 +1,20 @@
 package main
 
 import (
+	"os"
+
 	"github.com/skriptvalley/keyhouse/config"
 	"github.com/skriptvalley/keyhouse/pkg/app"
 	"github.com/skriptvalley/keyhouse/pkg/logger"
+	"github.com/skriptvalley/keyhouse/pkg/operator"
 	"go.uber.org/zap"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "operator" {
+		os.Exit(operator.Run(os.Args[2:]))
+	}
+
 	cfg := config.LoadConfig()
 	log := logger.NewLogger(cfg.LogLevel)
 	log.Info("Application starting", zap.Any("config", cfg))
@@ -7,13 +7,17 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/skriptvalley/keyhouse/pkg/snapshot"
 )
 
 // Config holds application-wide configuration
 type Config struct {
+	Dev            bool
 	LogLevel       string
 	AppVersion     string
 	ShutdownGrace  time.Duration
+	InitCodeTTL    time.Duration
 	HTTPPort       int
 	GRPCPort       int
 	SwaggerEnabled bool
@@ -22,6 +26,16 @@ type Config struct {
 	// Store
 	StoreType    string
 	StoreCfgPath string
+	CacheSize    int
+	// State database
+	StateType string
+	// Secrets
+	SecretMaxVersions int
+	// Snapshots
+	SnapshotSchedule    string
+	SnapshotDir         string
+	SnapshotRetainCount int
+	SnapshotRetainAge   time.Duration
 	// Redis
 	RedisHost     string
 	RedisPort     string
@@ -38,6 +52,7 @@ func SetConfigInEnvs(cfg *Config) {
 
 	writeEnv(file, "LOG_LEVEL", cfg.LogLevel)
 	writeEnv(file, "APP_VERSION", cfg.AppVersion)
+	writeEnv(file, "INIT_CODE_TTL", cfg.InitCodeTTL.String())
 	writeEnv(file, "HTTP_PORT", fmt.Sprintf("%d", cfg.HTTPPort))
 	writeEnv(file, "GRPC_PORT", fmt.Sprintf("%d", cfg.GRPCPort))
 	writeEnv(file, "SWAGGER_ENABLED", fmt.Sprintf("%t", cfg.SwaggerEnabled))
@@ -45,6 +60,13 @@ func SetConfigInEnvs(cfg *Config) {
 	writeEnv(file, "SWAGGER_DIR", cfg.SwaggerDir)
 	writeEnv(file, "STORE_TYPE", cfg.StoreType)
 	writeEnv(file, "STORE_CFG_PATH", cfg.StoreCfgPath)
+	writeEnv(file, "CACHE_SIZE", fmt.Sprintf("%d", cfg.CacheSize))
+	writeEnv(file, "STATE_TYPE", cfg.StateType)
+	writeEnv(file, "SECRET_MAX_VERSIONS", fmt.Sprintf("%d", cfg.SecretMaxVersions))
+	writeEnv(file, "SNAPSHOT_SCHEDULE", cfg.SnapshotSchedule)
+	writeEnv(file, "SNAPSHOT_DIR", cfg.SnapshotDir)
+	writeEnv(file, "SNAPSHOT_RETAIN_COUNT", fmt.Sprintf("%d", cfg.SnapshotRetainCount))
+	writeEnv(file, "SNAPSHOT_RETAIN_AGE", cfg.SnapshotRetainAge.String())
 	writeEnv(file, "REDIS_HOST", cfg.RedisHost)
 	writeEnv(file, "REDIS_PORT", cfg.RedisPort)
 	writeEnv(file, "REDIS_PASSWORD", cfg.RedisPassword)
@@ -86,17 +108,28 @@ func LoadConfig() *Config {
 	cfg := &Config{}
 
 	// Command-line flags
+	flag.BoolVar(&cfg.Dev, "dev", false, "run an in-memory, auto-unsealed dev server")
 	flag.StringVar(&cfg.LogLevel, "log-level", "info", "log level (debug, info, warn, error)")
 	flag.StringVar(&cfg.AppVersion, "app-version", "0.0.0", "application version")
 	flag.DurationVar(&cfg.ShutdownGrace, "shutdown-grace", 10*time.Second, "graceful shutdown timeout")
+	flag.DurationVar(&cfg.InitCodeTTL, "init-code-ttl", 24*time.Hour, "how long a new init code stays valid (0 never expires)")
 	flag.IntVar(&cfg.HTTPPort, "http-port", 8080, "application port")
 	flag.IntVar(&cfg.GRPCPort, "grpc-port", 9090, "gRPC server port")
 	flag.BoolVar(&cfg.SwaggerEnabled, "swagger-enabled", false, "enable Swagger UI")
 	flag.IntVar(&cfg.SwaggerPort, "swagger-port", 8081, "Swagger UI port")
 	flag.StringVar(&cfg.SwaggerDir, "swagger-dir", "./docs", "Swagger docs directory")
 	// Store configuration
-	flag.StringVar(&cfg.StoreType, "store-type", "postgres", "database type (postgres, mysql, etc.)")
+	flag.StringVar(&cfg.StoreType, "store-type", "postgres", "database type (postgres, redis, s3, etcd, file, memory)")
 	flag.StringVar(&cfg.StoreCfgPath, "store-cfg-path", "", "store configuration file path")
+	flag.IntVar(&cfg.CacheSize, "cache-size", 1024, "number of keystore entries cached in memory (0 disables the cache)")
+	flag.StringVar(&cfg.StateType, "state-type", "redis", "state database type (redis, postgres, memory); postgres shares the keystore connection")
+	// Secrets configuration
+	flag.IntVar(&cfg.SecretMaxVersions, "secret-max-versions", 10, "number of versions kept per secret")
+	// Snapshot configuration
+	flag.StringVar(&cfg.SnapshotSchedule, "snapshot-schedule", "", "take snapshots on an interval (e.g. 6h) or cron expression (e.g. \"0 3 * * *\"), disabled when empty")
+	flag.StringVar(&cfg.SnapshotDir, "snapshot-dir", "/var/lib/keyhouse/snapshots", "directory scheduled snapshots are written to")
+	flag.IntVar(&cfg.SnapshotRetainCount, "snapshot-retain-count", 7, "number of scheduled snapshots to keep (0 keeps all)")
+	flag.DurationVar(&cfg.SnapshotRetainAge, "snapshot-retain-age", 0, "delete scheduled snapshots older than this (0 keeps all)")
 	// Redis configuration
 	flag.StringVar(&cfg.RedisHost, "redis-host", "localhost", "Redis host")
 	flag.StringVar(&cfg.RedisPort, "redis-port", "6379", "Redis port")
@@ -113,7 +146,13 @@ func LoadConfig() *Config {
 		cfg.AppVersion = envAppVersion
 	}
 
-	SetConfigInEnvs(cfg)
+	if cfg.Dev {
+		// dev mode keeps everything in memory and leaves the host untouched
+		cfg.StoreType = "memory"
+		cfg.StateType = "memory"
+	} else {
+		SetConfigInEnvs(cfg)
+	}
 
 	if err := cfg.Validate(); err != nil {
 		log.Fatalf("config validation error: %v", err)
@@ -124,6 +163,32 @@ func LoadConfig() *Config {
 
 func (cfg *Config) Validate() error {
 	// Perform validation checks on the configuration
+	if cfg.InitCodeTTL < 0 {
+		return fmt.Errorf("init-code-ttl cannot be negative")
+	}
+	switch cfg.StateType {
+	case "redis", "memory":
+	case "postgres":
+		if cfg.StoreType != "postgres" {
+			return fmt.Errorf("state-type postgres requires store-type postgres")
+		}
+	default:
+		return fmt.Errorf("unknown state type %s", cfg.StateType)
+	}
+	if cfg.CacheSize < 0 {
+		return fmt.Errorf("cache-size cannot be negative")
+	}
+	if cfg.SnapshotSchedule != "" {
+		if _, err := snapshot.ParseSchedule(cfg.SnapshotSchedule); err != nil {
+			return err
+		}
+	}
+	if cfg.SnapshotRetainCount < 0 || cfg.SnapshotRetainAge < 0 {
+		return fmt.Errorf("snapshot retention cannot be negative")
+	}
+	if cfg.SecretMaxVersions < 1 {
+		return fmt.Errorf("secret-max-versions must be at least 1")
+	}
 
 	return nil
 }
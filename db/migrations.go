@@ -0,0 +1,10 @@
+package db
+
+import "embed"
+
+// Migrations holds the versioned schema migrations applied by the postgres
+// keystore on startup. Files are named <version>_<name>.up.sql and
+// <version>_<name>.down.sql.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "keyhouse_nodes";
+DROP TABLE IF EXISTS "keyhouse_keyholders";
+DROP TABLE IF EXISTS "keyhouse_state";
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "keyhouse_state" (
+    key        TEXT PRIMARY KEY,
+    value      TEXT NOT NULL,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS "keyhouse_keyholders" (
+    id     TEXT PRIMARY KEY,
+    active BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS "keyhouse_nodes" (
+    node      TEXT PRIMARY KEY,
+    last_seen TIMESTAMPTZ NOT NULL
+);
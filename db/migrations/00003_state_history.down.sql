@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "keyhouse_state_history";
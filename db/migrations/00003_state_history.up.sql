@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "keyhouse_state_history" (
+    id         BIGSERIAL PRIMARY KEY,
+    changed_at TIMESTAMPTZ NOT NULL,
+    from_state TEXT NOT NULL,
+    to_state   TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    reason     TEXT NOT NULL,
+    node       TEXT NOT NULL
+);
@@ -2,12 +2,14 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/skriptvalley/keyhouse/config"
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
 	"github.com/skriptvalley/keyhouse/pkg/server"
 	"github.com/skriptvalley/keyhouse/pkg/statemanager"
 
@@ -20,6 +22,9 @@ type App struct {
 	config *config.Config
 	server *server.Server
 	sm     *statemanager.StateManager
+
+	stopHeartbeat context.CancelFunc
+	heartbeatDone chan struct{}
 }
 
 // NewApp initializes a new App instance with routing and middleware
@@ -27,10 +32,15 @@ func NewApp(cfg *config.Config, logger *zap.Logger) *App {
 	ctx := context.Background()
 	var err error
 
-	statemgr := statemanager.NewStateManager(logger, cfg.RedisHost, cfg.RedisPort, cfg.RedisPassword)
+	beStore := server.OpenKeystore(logger, cfg)
+	statemgr, err := newStateManager(logger, cfg, beStore)
+	if err != nil {
+		logger.Fatal("Failed to initialize state db", zap.String("method", "NewApp"), zap.Error(err))
+	}
+	statemgr.SetInitCodeTTL(cfg.InitCodeTTL)
 	err = statemgr.Ping(ctx)
 	if err != nil {
-		logger.Fatal("Failed to connect to redis", zap.String("method", "NewApp"), zap.Error(err))
+		logger.Fatal("Failed to connect to state db", zap.String("method", "NewApp"), zap.String("state_type", cfg.StateType), zap.Error(err))
 	}
 	err = statemgr.InitStateDBCache(ctx)
 	if err != nil {
@@ -40,15 +50,62 @@ func NewApp(cfg *config.Config, logger *zap.Logger) *App {
 	app := &App{
 		logger: logger,
 		config: cfg,
-		server: server.NewServer(logger, cfg, statemgr),
+		server: server.NewServer(logger, cfg, statemgr, beStore),
 		sm:     statemgr,
 	}
+	if cfg.Dev {
+		app.initDevVault(ctx)
+	}
 	return app
 }
 
+// newStateManager builds the state manager on the configured state db. The
+// postgres state db shares the connection of the postgres keystore.
+func newStateManager(logger *zap.Logger, cfg *config.Config, beStore keystore.BackendKeyStore) (*statemanager.StateManager, error) {
+	switch cfg.StateType {
+	case "memory":
+		return statemanager.NewStateManagerWithDB(logger, statemanager.NewMemoryDB(logger)), nil
+	case "postgres":
+		pg, ok := beStore.(*keystore.PostgresStore)
+		if !ok {
+			return nil, fmt.Errorf("state-type postgres requires store-type postgres")
+		}
+		return statemanager.NewStateManagerWithDB(logger, statemanager.NewPostgresDB(logger, pg.DB(), pg.ConnString())), nil
+	case "redis":
+		return statemanager.NewStateManager(logger, cfg.RedisHost, cfg.RedisPort, cfg.RedisPassword), nil
+	default:
+		return nil, fmt.Errorf("unknown state type %s", cfg.StateType)
+	}
+}
+
+// initDevVault initializes the vault with a single unseal key and unseals it
+// right away, so a dev server is usable without any keyholder interaction.
+func (a *App) initDevVault(ctx context.Context) {
+	ctx = statemanager.WithActor(ctx, "dev")
+	keys, rootToken, err := a.sm.GenerateKeys(ctx, 1, 1)
+	if err != nil {
+		a.logger.Fatal("Failed to initialize dev vault", zap.String("method", "initDevVault"), zap.Error(err))
+	}
+	ready, err := a.sm.UnlockVault(ctx, keys[0], "")
+	if err != nil || !ready {
+		a.logger.Fatal("Failed to unseal dev vault", zap.String("method", "initDevVault"), zap.Error(err))
+	}
+
+	fmt.Println("==> Keyhouse is running in dev mode. Do NOT use dev mode in production!")
+	fmt.Println("")
+	fmt.Println("All data is kept in memory and is lost on shutdown. The vault is")
+	fmt.Println("initialized and unsealed with a single unseal key. The root token")
+	fmt.Println("authorizes privileged calls:")
+	fmt.Println("")
+	fmt.Printf("Unseal Key: %s\n", keys[0])
+	fmt.Printf("Root Token: %s\n", rootToken)
+	fmt.Println("")
+}
+
 // Run starts the application with graceful shutdown
 func (a *App) Run() {
 	a.VaultStateChecks()
+	a.startHeartbeat()
 	// API Server
 	a.server.Start()
 
@@ -67,9 +124,24 @@ func (a *App) gracefulShutdown() {
 	defer cancel()
 
 	a.server.Shutdown(ctx)
+	a.stopHeartbeat()
+	<-a.heartbeatDone
 	a.logger.Info("Server gracefully stopped.")
 }
 
+// startHeartbeat registers this server in the state db for as long as it
+// runs, so operator commands can tell it is using the keystore.
+func (a *App) startHeartbeat() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopHeartbeat = cancel
+	a.heartbeatDone = make(chan struct{})
+	go func() {
+		defer close(a.heartbeatDone)
+		a.sm.RunHeartbeat(ctx)
+	}()
+	a.logger.Info("Registered keyhouse node", zap.String("node", a.sm.NodeID()))
+}
+
 func (a *App) VaultStateChecks() {
 	ctx := context.Background()
 	down := a.sm.IsVaultDown(ctx)
@@ -84,7 +156,7 @@ func (a *App) VaultStateChecks() {
 	}
 	ready := a.sm.IsVaultReady(ctx)
 	if !ready {
-		a.logger.Info("==> Vault is not ready, please unlock the vault with minimum of 3 activation keys",
+		a.logger.Info("==> Vault is not ready, please unlock the vault with the configured threshold of unseal keys",
 			zap.String("method", "VaultStateChecks"))
 	} else {
 		a.logger.Info("Vault is ready", zap.String("method", "VaultStateChecks"))
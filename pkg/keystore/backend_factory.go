@@ -1,14 +1,48 @@
 package keystore
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 type KeystoreType string
 
+// Storage IDs used by keyhouse
+const (
+	SYSTEM_STORAGE          = "system"
+	SECRETS_STORAGE         = "secrets"
+	SECRET_VERSIONS_STORAGE = "secret_versions"
+)
+
+// StorageIDs lists every storage id, for tools that copy a whole keystore.
+var StorageIDs = []string{SYSTEM_STORAGE, SECRETS_STORAGE, SECRET_VERSIONS_STORAGE}
+
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrKeyTooLong is returned by backends that cannot store a key that long.
+var ErrKeyTooLong = errors.New("key is too long")
+
+// BackendKeyStore is the physical storage of keyhouse. Retrieve returns
+// ErrKeyNotFound when the key does not exist.
+//
+// List returns the direct children of prefix in lexical byte order, relative
+// to prefix. Keys nested further down are folded into a single entry ending
+// in "/". Listing resumes after cursor and returns at most limit entries
+// (all when limit <= 0) along with the cursor of the next page, which is
+// empty on the last page.
 type BackendKeyStore interface {
 	Ping() error
 	Store(storageId, key string, value []byte) error
 	Retrieve(storageId, key string) ([]byte, error)
 	Delete(storageId, key string) error
+	List(storageId, prefix, cursor string, limit int) ([]string, string, error)
+	Close() error
+}
+
+// Migrator is implemented by backends that manage their own schema or
+// layout. Migrate is called once on startup after the backend is reachable.
+type Migrator interface {
+	Migrate() error
 }
 
 func NewKeystore(storeType, cfgPath string) (BackendKeyStore, error) {
@@ -19,6 +53,32 @@ func NewKeystore(storeType, cfgPath string) (BackendKeyStore, error) {
 			return nil, err
 		}
 		return NewPostgresStore(pgCfg)
+	case "memory":
+		return NewMemoryStore(), nil
+	case "file":
+		fileCfg, err := LoadFileConfig(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStore(fileCfg)
+	case "redis":
+		redisCfg, err := LoadRedisConfig(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedisStore(redisCfg)
+	case "s3":
+		s3Cfg, err := LoadS3Config(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(s3Cfg)
+	case "etcd":
+		etcdCfg, err := LoadEtcdConfig(cfgPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewEtcdStore(etcdCfg)
 	default:
 		return nil, fmt.Errorf("unknown store type %s", storeType)
 	}
@@ -0,0 +1,287 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	KEYRING_KEY = "keyring"
+
+	DATA_KEY_SIZE   = 32
+	BARRIER_VERSION = byte(1)
+)
+
+var (
+	ErrBarrierSealed         = errors.New("barrier is sealed")
+	ErrBarrierNotInitialized = errors.New("barrier is not initialized")
+	ErrInvalidMasterKey      = errors.New("invalid master key")
+	ErrSealDigestMismatch    = errors.New("seal config does not match the keyring")
+)
+
+// keyring holds the data key used to encrypt every entry. It is persisted
+// encrypted by the unseal master key.
+type keyring struct {
+	Term      int       `json:"term"`
+	DataKey   []byte    `json:"data_key"`
+	Installed time.Time `json:"installed"`
+	// SealDigest binds the seal config the master key was split with to
+	// the keyring, so it cannot be swapped without the master key
+	SealDigest string `json:"seal_digest"`
+}
+
+// SecurityBarrier is a BackendKeyStore that encrypts every value and
+// refuses all access while sealed.
+type SecurityBarrier interface {
+	BackendKeyStore
+	Initialize(masterKey []byte, sealDigest string) error
+	Unseal(masterKey []byte, sealDigest string) error
+	Seal()
+	Sealed() bool
+}
+
+// AESGCMBarrier wraps a BackendKeyStore and encrypts every value with
+// AES-256-GCM. It refuses all access until it is unsealed with the master key.
+type AESGCMBarrier struct {
+	backend BackendKeyStore
+
+	l      sync.RWMutex
+	sealed bool
+	term   int
+	aead   cipher.AEAD
+}
+
+// NewBarrier wraps backend in a sealed barrier. The barrier supports
+// transactions if the backend does.
+func NewBarrier(backend BackendKeyStore) SecurityBarrier {
+	b := &AESGCMBarrier{
+		backend: backend,
+		sealed:  true,
+	}
+	if _, ok := backend.(Transactional); ok {
+		return &transactionalBarrier{b}
+	}
+	return b
+}
+
+// Initialize generates a new data key and stores it encrypted by the given
+// master key along with sealDigest, replacing any existing keyring. The
+// barrier stays sealed.
+func (b *AESGCMBarrier) Initialize(masterKey []byte, sealDigest string) error {
+	dataKey := make([]byte, DATA_KEY_SIZE)
+	if _, err := rand.Read(dataKey); err != nil {
+		return fmt.Errorf("failed to generate data key: %w", err)
+	}
+	defer ZeroBytes(dataKey)
+
+	plaintext, err := json.Marshal(&keyring{
+		Term:       1,
+		DataKey:    dataKey,
+		Installed:  time.Now().UTC(),
+		SealDigest: sealDigest,
+	})
+	if err != nil {
+		return err
+	}
+	defer ZeroBytes(plaintext)
+
+	gcm, err := newGCM(masterKey)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := encrypt(gcm, []byte(SYSTEM_STORAGE+"/"+KEYRING_KEY), plaintext)
+	if err != nil {
+		return err
+	}
+	return b.backend.Store(SYSTEM_STORAGE, KEYRING_KEY, ciphertext)
+}
+
+// Unseal decrypts the keyring with the master key and opens the barrier,
+// unless the keyring was initialized with another seal digest.
+func (b *AESGCMBarrier) Unseal(masterKey []byte, sealDigest string) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	if !b.sealed {
+		return nil
+	}
+
+	ciphertext, err := b.backend.Retrieve(SYSTEM_STORAGE, KEYRING_KEY)
+	if errors.Is(err, ErrKeyNotFound) {
+		return ErrBarrierNotInitialized
+	} else if err != nil {
+		return fmt.Errorf("failed to read keyring: %w", err)
+	}
+	gcm, err := newGCM(masterKey)
+	if err != nil {
+		return err
+	}
+	plaintext, err := decrypt(gcm, []byte(SYSTEM_STORAGE+"/"+KEYRING_KEY), ciphertext)
+	if err != nil {
+		return ErrInvalidMasterKey
+	}
+	defer ZeroBytes(plaintext)
+
+	var kr keyring
+	if err = json.Unmarshal(plaintext, &kr); err != nil {
+		return fmt.Errorf("failed to decode keyring: %w", err)
+	}
+	defer ZeroBytes(kr.DataKey)
+	if subtle.ConstantTimeCompare([]byte(kr.SealDigest), []byte(sealDigest)) != 1 {
+		return ErrSealDigestMismatch
+	}
+	aead, err := newGCM(kr.DataKey)
+	if err != nil {
+		return err
+	}
+
+	b.term = kr.Term
+	b.aead = aead
+	b.sealed = false
+	return nil
+}
+
+// Seal drops the data key from memory, along with anything the backend
+// holds in memory. Every access fails until the barrier is unsealed again.
+func (b *AESGCMBarrier) Seal() {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.aead = nil
+	b.sealed = true
+	if purger, ok := b.backend.(Purger); ok {
+		purger.Purge()
+	}
+}
+
+func (b *AESGCMBarrier) Sealed() bool {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	return b.sealed
+}
+
+func (b *AESGCMBarrier) Ping() error {
+	return b.backend.Ping()
+}
+
+func (b *AESGCMBarrier) Store(storageId, key string, value []byte) error {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+	ciphertext, err := encrypt(b.aead, entryAAD(storageId, key), value)
+	if err != nil {
+		return err
+	}
+	return b.backend.Store(storageId, key, ciphertext)
+}
+
+func (b *AESGCMBarrier) Retrieve(storageId, key string) ([]byte, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return nil, ErrBarrierSealed
+	}
+	ciphertext, err := b.backend.Retrieve(storageId, key)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(b.aead, entryAAD(storageId, key), ciphertext)
+}
+
+func (b *AESGCMBarrier) Delete(storageId, key string) error {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+	return b.backend.Delete(storageId, key)
+}
+
+func (b *AESGCMBarrier) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.sealed {
+		return nil, "", ErrBarrierSealed
+	}
+	return b.backend.List(storageId, prefix, cursor, limit)
+}
+
+func (b *AESGCMBarrier) Close() error {
+	b.Seal()
+	return b.backend.Close()
+}
+
+type transactionalBarrier struct {
+	*AESGCMBarrier
+}
+
+func (t *transactionalBarrier) Transaction(ops []Operation) error {
+	t.l.RLock()
+	defer t.l.RUnlock()
+	if t.sealed {
+		return ErrBarrierSealed
+	}
+	encrypted := make([]Operation, len(ops))
+	for i, op := range ops {
+		encrypted[i] = op
+		if op.Type != PUT_OPERATION {
+			continue
+		}
+		ciphertext, err := encrypt(t.aead, entryAAD(op.StorageId, op.Key), op.Value)
+		if err != nil {
+			return err
+		}
+		encrypted[i].Value = ciphertext
+	}
+	return t.backend.(Transactional).Transaction(encrypted)
+}
+
+// entryAAD binds a ciphertext to its location so entries cannot be swapped.
+func entryAAD(storageId, key string) []byte {
+	return []byte(storageId + "/" + key)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt returns version || nonce || ciphertext.
+func encrypt(gcm cipher.AEAD, aad, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	out := make([]byte, 0, 1+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, BARRIER_VERSION)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, aad), nil
+}
+
+func decrypt(gcm cipher.AEAD, aad, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 1+gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	if ciphertext[0] != BARRIER_VERSION {
+		return nil, fmt.Errorf("unsupported barrier version %d", ciphertext[0])
+	}
+	nonce := ciphertext[1 : 1+gcm.NonceSize()]
+	return gcm.Open(nil, nonce, ciphertext[1+gcm.NonceSize():], aad)
+}
+
+// ZeroBytes overwrites key material once it is no longer needed.
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
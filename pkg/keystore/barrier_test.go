@@ -0,0 +1,149 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+const testSealDigest = "seal-digest"
+
+func testMasterKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, DATA_KEY_SIZE)
+}
+
+func TestBarrierUnseal(t *testing.T) {
+	tests := []struct {
+		name       string
+		initialize bool
+		masterKey  []byte
+		sealDigest string
+		want       error
+	}{
+		{"matching key and digest", true, testMasterKey(1), testSealDigest, nil},
+		{"wrong master key", true, testMasterKey(2), testSealDigest, ErrInvalidMasterKey},
+		{"wrong seal digest", true, testMasterKey(1), "other-digest", ErrSealDigestMismatch},
+		{"not initialized", false, testMasterKey(1), testSealDigest, ErrBarrierNotInitialized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			barrier := NewBarrier(NewMemoryStore())
+			if tt.initialize {
+				if err := barrier.Initialize(testMasterKey(1), testSealDigest); err != nil {
+					t.Fatalf("Initialize: %v", err)
+				}
+			}
+			if !barrier.Sealed() {
+				t.Fatalf("barrier is unsealed before Unseal")
+			}
+			err := barrier.Unseal(tt.masterKey, tt.sealDigest)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Unseal: got %v, want %v", err, tt.want)
+			}
+			if barrier.Sealed() != (tt.want != nil) {
+				t.Fatalf("Sealed() = %v after Unseal returned %v", barrier.Sealed(), err)
+			}
+		})
+	}
+}
+
+func TestBarrierSealed(t *testing.T) {
+	backend := NewMemoryStore()
+	barrier := NewBarrier(backend)
+	if err := barrier.Initialize(testMasterKey(1), testSealDigest); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := barrier.Unseal(testMasterKey(1), testSealDigest); err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	if err := barrier.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	barrier.Seal()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"store", func() error { return barrier.Store(SECRETS_STORAGE, "app/db", []byte("x")) }},
+		{"retrieve", func() error {
+			_, err := barrier.Retrieve(SECRETS_STORAGE, "app/db")
+			return err
+		}},
+		{"delete", func() error { return barrier.Delete(SECRETS_STORAGE, "app/db") }},
+		{"list", func() error {
+			_, _, err := barrier.List(SECRETS_STORAGE, "", "", 0)
+			return err
+		}},
+		{"transaction", func() error {
+			return ApplyOperations(barrier, []Operation{{Type: DELETE_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db"}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrBarrierSealed) {
+				t.Fatalf("got %v, want %v", err, ErrBarrierSealed)
+			}
+		})
+	}
+	if _, err := backend.Retrieve(SECRETS_STORAGE, "app/db"); err != nil {
+		t.Fatalf("entry was changed while sealed: %v", err)
+	}
+}
+
+func TestBarrierEncryption(t *testing.T) {
+	backend := NewMemoryStore()
+	barrier := NewBarrier(backend)
+	if err := barrier.Initialize(testMasterKey(1), testSealDigest); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := barrier.Unseal(testMasterKey(1), testSealDigest); err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	entries := map[string][]byte{
+		"app/db":  []byte("hunter2"),
+		"app/api": []byte("swordfish"),
+	}
+	ops := make([]Operation, 0, len(entries))
+	for key, value := range entries {
+		ops = append(ops, Operation{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: key, Value: value})
+	}
+	if err := ApplyOperations(barrier, ops); err != nil {
+		t.Fatalf("ApplyOperations: %v", err)
+	}
+
+	// a second barrier over the same backend must unseal to the same data
+	// key
+	reopened := NewBarrier(backend)
+	if err := reopened.Unseal(testMasterKey(1), testSealDigest); err != nil {
+		t.Fatalf("Unseal: %v", err)
+	}
+	for key, value := range entries {
+		stored, err := backend.Retrieve(SECRETS_STORAGE, key)
+		if err != nil {
+			t.Fatalf("backend Retrieve %s: %v", key, err)
+		}
+		if bytes.Contains(stored, value) {
+			t.Fatalf("%s is stored in plaintext", key)
+		}
+		got, err := reopened.Retrieve(SECRETS_STORAGE, key)
+		if err != nil {
+			t.Fatalf("Retrieve %s: %v", key, err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Fatalf("Retrieve %s: got %q, want %q", key, got, value)
+		}
+	}
+
+	// ciphertexts are bound to their key
+	stored, err := backend.Retrieve(SECRETS_STORAGE, "app/db")
+	if err != nil {
+		t.Fatalf("backend Retrieve: %v", err)
+	}
+	if err = backend.Store(SECRETS_STORAGE, "app/api", stored); err != nil {
+		t.Fatalf("backend Store: %v", err)
+	}
+	if _, err = reopened.Retrieve(SECRETS_STORAGE, "app/api"); err == nil {
+		t.Fatalf("Retrieve of a moved ciphertext succeeded")
+	}
+}
@@ -0,0 +1,222 @@
+package keystore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Invalidator broadcasts cache invalidations between replicas that share a
+// backend. Implementations log their own failures.
+type Invalidator interface {
+	PublishInvalidation(storageId, key string)
+	// SubscribeInvalidations blocks until ctx is done, calling invalidate for
+	// every invalidation published by any replica and purge whenever the
+	// subscription is (re)established, since messages may have been missed.
+	SubscribeInvalidations(ctx context.Context, invalidate func(storageId, key string), purge func())
+}
+
+// Purger is implemented by stores that hold data in memory which must be
+// dropped when the vault seals.
+type Purger interface {
+	Purge()
+}
+
+// CachedKeyStore is a BackendKeyStore that serves reads from memory.
+type CachedKeyStore interface {
+	BackendKeyStore
+	Purger
+	Invalidate(storageId, key string)
+	Watch(inv Invalidator)
+	Stats() CacheStats
+}
+
+type CacheStats struct {
+	Hits     uint64
+	Misses   uint64
+	Entries  int
+	Capacity int
+}
+
+type cacheKey struct {
+	storageId string
+	key       string
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	value []byte
+}
+
+// Cache is a read-through LRU in front of a BackendKeyStore. It sits below
+// the barrier, so it only ever holds encrypted entries.
+type Cache struct {
+	backend BackendKeyStore
+	size    int
+
+	l       sync.Mutex
+	lru     *list.List
+	entries map[cacheKey]*list.Element
+	// generation is bumped on every invalidation so a read that raced with a
+	// write does not put a stale value back
+	generation uint64
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	invalidator Invalidator
+	stop        context.CancelFunc
+}
+
+// NewCache wraps backend in a cache holding at most size entries. The cache
+// supports transactions if the backend does.
+func NewCache(backend BackendKeyStore, size int) CachedKeyStore {
+	c := &Cache{
+		backend: backend,
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[cacheKey]*list.Element),
+	}
+	if _, ok := backend.(Transactional); ok {
+		return &transactionalCache{c}
+	}
+	return c
+}
+
+// Watch publishes local writes through inv and applies the invalidations of
+// other replicas until the cache is closed.
+func (c *Cache) Watch(inv Invalidator) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.l.Lock()
+	c.invalidator = inv
+	c.stop = cancel
+	c.l.Unlock()
+	go inv.SubscribeInvalidations(ctx, c.Invalidate, c.Purge)
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return CacheStats{
+		Hits:     c.hits.Load(),
+		Misses:   c.misses.Load(),
+		Entries:  c.lru.Len(),
+		Capacity: c.size,
+	}
+}
+
+func (c *Cache) Ping() error {
+	return c.backend.Ping()
+}
+
+func (c *Cache) Store(storageId, key string, value []byte) error {
+	err := c.backend.Store(storageId, key, value)
+	c.invalidateAndPublish(cacheKey{storageId, key})
+	return err
+}
+
+func (c *Cache) Retrieve(storageId, key string) ([]byte, error) {
+	k := cacheKey{storageId, key}
+	c.l.Lock()
+	if elem, ok := c.entries[k]; ok {
+		c.lru.MoveToFront(elem)
+		value := elem.Value.(*cacheEntry).value
+		c.l.Unlock()
+		c.hits.Add(1)
+		return append([]byte(nil), value...), nil
+	}
+	generation := c.generation
+	c.l.Unlock()
+	c.misses.Add(1)
+
+	value, err := c.backend.Retrieve(storageId, key)
+	if err != nil {
+		return nil, err
+	}
+	c.add(k, value, generation)
+	return value, nil
+}
+
+func (c *Cache) Delete(storageId, key string) error {
+	err := c.backend.Delete(storageId, key)
+	c.invalidateAndPublish(cacheKey{storageId, key})
+	return err
+}
+
+func (c *Cache) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	return c.backend.List(storageId, prefix, cursor, limit)
+}
+
+func (c *Cache) Close() error {
+	c.l.Lock()
+	if c.stop != nil {
+		c.stop()
+	}
+	c.l.Unlock()
+	c.Purge()
+	return c.backend.Close()
+}
+
+// Invalidate drops a single entry.
+func (c *Cache) Invalidate(storageId, key string) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.generation++
+	if elem, ok := c.entries[cacheKey{storageId, key}]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, cacheKey{storageId, key})
+	}
+}
+
+// Purge drops every entry.
+func (c *Cache) Purge() {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.generation++
+	c.lru.Init()
+	c.entries = make(map[cacheKey]*list.Element)
+}
+
+func (c *Cache) add(k cacheKey, value []byte, generation uint64) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.generation != generation {
+		return
+	}
+	if elem, ok := c.entries[k]; ok {
+		elem.Value.(*cacheEntry).value = append([]byte(nil), value...)
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[k] = c.lru.PushFront(&cacheEntry{key: k, value: append([]byte(nil), value...)})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidateAndPublish runs after every write, successful or not, since a
+// failed write may still have reached the backend.
+func (c *Cache) invalidateAndPublish(k cacheKey) {
+	c.Invalidate(k.storageId, k.key)
+	c.l.Lock()
+	inv := c.invalidator
+	c.l.Unlock()
+	if inv != nil {
+		inv.PublishInvalidation(k.storageId, k.key)
+	}
+}
+
+type transactionalCache struct {
+	*Cache
+}
+
+func (t *transactionalCache) Transaction(ops []Operation) error {
+	err := t.backend.(Transactional).Transaction(ops)
+	for _, op := range ops {
+		t.invalidateAndPublish(cacheKey{op.StorageId, op.Key})
+	}
+	return err
+}
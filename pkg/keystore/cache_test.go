@@ -0,0 +1,184 @@
+package keystore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// recordingInvalidator keeps published invalidations and hands the
+// subscriber callbacks to the test.
+type recordingInvalidator struct {
+	l          sync.Mutex
+	published  []cacheKey
+	invalidate func(storageId, key string)
+	subscribed chan struct{}
+}
+
+func newRecordingInvalidator() *recordingInvalidator {
+	return &recordingInvalidator{subscribed: make(chan struct{})}
+}
+
+func (r *recordingInvalidator) PublishInvalidation(storageId, key string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.published = append(r.published, cacheKey{storageId, key})
+}
+
+func (r *recordingInvalidator) SubscribeInvalidations(ctx context.Context, invalidate func(storageId, key string), purge func()) {
+	purge()
+	r.l.Lock()
+	r.invalidate = invalidate
+	r.l.Unlock()
+	close(r.subscribed)
+	<-ctx.Done()
+}
+
+func (r *recordingInvalidator) Published() []cacheKey {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return append([]cacheKey(nil), r.published...)
+}
+
+func retrieveString(t *testing.T, be BackendKeyStore, key string) string {
+	t.Helper()
+	value, err := be.Retrieve(SECRETS_STORAGE, key)
+	if err != nil {
+		t.Fatalf("Retrieve %s: %v", key, err)
+	}
+	return string(value)
+}
+
+func TestCacheReadThrough(t *testing.T) {
+	backend := NewMemoryStore()
+	if err := backend.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	cache := NewCache(backend, 10)
+	for i := 0; i < 3; i++ {
+		if got := retrieveString(t, cache, "app/db"); got != "hunter2" {
+			t.Fatalf("got %q, want %q", got, "hunter2")
+		}
+	}
+	if _, err := cache.Retrieve(SECRETS_STORAGE, "app/api"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("missing key: got %v, want %v", err, ErrKeyNotFound)
+	}
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 1 || stats.Capacity != 10 {
+		t.Fatalf("got %+v", stats)
+	}
+
+	// a write that bypasses the cache is only seen after a purge
+	if err := backend.Store(SECRETS_STORAGE, "app/db", []byte("swordfish")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	if got := retrieveString(t, cache, "app/db"); got != "hunter2" {
+		t.Fatalf("got %q before Purge, want the cached value", got)
+	}
+	cache.Purge()
+	if got := retrieveString(t, cache, "app/db"); got != "swordfish" {
+		t.Fatalf("got %q after Purge, want %q", got, "swordfish")
+	}
+}
+
+func TestCacheWritesInvalidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(cache CachedKeyStore) error
+		want  string
+	}{
+		{"store", func(cache CachedKeyStore) error {
+			return cache.Store(SECRETS_STORAGE, "app/db", []byte("swordfish"))
+		}, "swordfish"},
+		{"delete", func(cache CachedKeyStore) error {
+			return cache.Delete(SECRETS_STORAGE, "app/db")
+		}, ""},
+		{"transaction", func(cache CachedKeyStore) error {
+			return ApplyOperations(cache, []Operation{
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db", Value: []byte("swordfish")},
+			})
+		}, "swordfish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache(NewMemoryStore(), 10)
+			inv := newRecordingInvalidator()
+			cache.Watch(inv)
+			defer cache.Close()
+			if err := cache.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			retrieveString(t, cache, "app/db")
+			if err := tt.write(cache); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			value, err := cache.Retrieve(SECRETS_STORAGE, "app/db")
+			if tt.want == "" && !errors.Is(err, ErrKeyNotFound) {
+				t.Fatalf("got %q, %v, want %v", value, err, ErrKeyNotFound)
+			}
+			if string(value) != tt.want {
+				t.Fatalf("got %q, want %q", value, tt.want)
+			}
+			published := inv.Published()
+			if len(published) != 2 || published[1] != (cacheKey{SECRETS_STORAGE, "app/db"}) {
+				t.Fatalf("published %v", published)
+			}
+		})
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	backend := NewMemoryStore()
+	for _, key := range []string{"a", "b", "c"} {
+		if err := backend.Store(SECRETS_STORAGE, key, []byte(key)); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+	cache := NewCache(backend, 2)
+	// "b" is the least recently used entry when "c" is added
+	for _, key := range []string{"a", "b", "a", "c"} {
+		retrieveString(t, cache, key)
+	}
+	if stats := cache.Stats(); stats.Entries != 2 || stats.Misses != 3 {
+		t.Fatalf("got %+v", stats)
+	}
+	for _, tt := range []struct {
+		key    string
+		misses uint64
+	}{
+		{"a", 3},
+		{"c", 3},
+		{"b", 4},
+	} {
+		retrieveString(t, cache, tt.key)
+		if misses := cache.Stats().Misses; misses != tt.misses {
+			t.Fatalf("after reading %s: got %d misses, want %d", tt.key, misses, tt.misses)
+		}
+	}
+}
+
+func TestCacheRemoteInvalidations(t *testing.T) {
+	backend := NewMemoryStore()
+	for _, key := range []string{"a", "b"} {
+		if err := backend.Store(SECRETS_STORAGE, key, []byte(key)); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+	cache := NewCache(backend, 10)
+	inv := newRecordingInvalidator()
+	cache.Watch(inv)
+	defer cache.Close()
+	<-inv.subscribed
+
+	retrieveString(t, cache, "a")
+	retrieveString(t, cache, "b")
+	inv.invalidate(SECRETS_STORAGE, "a")
+	if entries := cache.Stats().Entries; entries != 1 {
+		t.Fatalf("got %d entries after a remote invalidation, want 1", entries)
+	}
+	cache.Purge()
+	if entries := cache.Stats().Entries; entries != 0 {
+		t.Fatalf("got %d entries after Purge, want 0", entries)
+	}
+}
@@ -0,0 +1,205 @@
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ETCD_DEFAULT_PREFIX  = "/keyhouse/"
+	ETCD_DEFAULT_TIMEOUT = 5 * time.Second
+	// ETCD_LIST_BATCH is the number of keys List reads per request
+	ETCD_LIST_BATCH = 1000
+)
+
+var (
+	etcdConfigFilePath = "/var/db/etcd_config.yaml"
+)
+
+type EtcdConfig struct {
+	Endpoints []string `yaml:"endpoints"`
+	Username  string   `yaml:"username"`
+	Password  string   `yaml:"password"`
+	// Prefix namespaces every key, as <prefix><storage id>/<key>
+	Prefix         string        `yaml:"prefix"`
+	DialTimeout    time.Duration `yaml:"dial_timeout"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// TLS client certificate authentication
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	CAFile     string `yaml:"ca_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// EtcdStore keeps every entry as an etcd key below a common prefix.
+type EtcdStore struct {
+	client  *clientv3.Client
+	prefix  string
+	timeout time.Duration
+}
+
+func NewEtcdStore(cfg EtcdConfig) (*EtcdStore, error) {
+	if len(cfg.Endpoints) == 0 {
+		return nil, fmt.Errorf("at least one etcd endpoint is required")
+	}
+	if cfg.DialTimeout == 0 {
+		cfg.DialTimeout = ETCD_DEFAULT_TIMEOUT
+	}
+	if cfg.RequestTimeout == 0 {
+		cfg.RequestTimeout = ETCD_DEFAULT_TIMEOUT
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = ETCD_DEFAULT_PREFIX
+	}
+	if !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+
+	clientCfg := clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		DialTimeout: cfg.DialTimeout,
+	}
+	if cfg.CertFile != "" || cfg.CAFile != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      cfg.CertFile,
+			KeyFile:       cfg.KeyFile,
+			TrustedCAFile: cfg.CAFile,
+			ServerName:    cfg.ServerName,
+		}
+		tlsCfg, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load etcd TLS config: %w", err)
+		}
+		clientCfg.TLS = tlsCfg
+	}
+	client, err := clientv3.New(clientCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+	return &EtcdStore{client: client, prefix: cfg.Prefix, timeout: cfg.RequestTimeout}, nil
+}
+
+func (e *EtcdStore) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	_, err := e.client.Get(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	return err
+}
+
+func (e *EtcdStore) Store(storageId, key string, value []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	_, err := e.client.Put(ctx, e.etcdKey(storageId, key), string(value))
+	return err
+}
+
+func (e *EtcdStore) Retrieve(storageId, key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	resp, err := e.client.Get(ctx, e.etcdKey(storageId, key))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrKeyNotFound
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+func (e *EtcdStore) Delete(storageId, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	_, err := e.client.Delete(ctx, e.etcdKey(storageId, key))
+	return err
+}
+
+// List reads the keys below prefix in sorted batches from the cursor on and
+// stops once the page is full, so a page only reads the keys it returns and
+// those of the folders it passes.
+func (e *EtcdStore) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	base := e.etcdKey(storageId, "")
+	start := base + prefix + cursor
+	end := clientv3.GetPrefixRangeEnd(base + prefix)
+	batch := int64(ETCD_LIST_BATCH)
+	if limit > 0 && limit < ETCD_LIST_BATCH {
+		batch = int64(limit) + 1
+	}
+	children := newSortedChildren(prefix, cursor, limit)
+	for {
+		resp, err := e.client.Get(ctx, start,
+			clientv3.WithRange(end),
+			clientv3.WithLimit(batch),
+			clientv3.WithKeysOnly(),
+			clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+		if err != nil {
+			return nil, "", err
+		}
+		full := false
+		for _, kv := range resp.Kvs {
+			if full = children.add(strings.TrimPrefix(string(kv.Key), base)); full {
+				break
+			}
+		}
+		if full || !resp.More || len(resp.Kvs) == 0 {
+			break
+		}
+		// the next batch starts right after the last key read
+		start = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+	page, next := children.page()
+	return page, next, nil
+}
+
+// Transaction applies ops in a single etcd transaction.
+func (e *EtcdStore) Transaction(ops []Operation) error {
+	etcdOps := make([]clientv3.Op, 0, len(ops))
+	for _, op := range ops {
+		switch op.Type {
+		case PUT_OPERATION:
+			etcdOps = append(etcdOps, clientv3.OpPut(e.etcdKey(op.StorageId, op.Key), string(op.Value)))
+		case DELETE_OPERATION:
+			etcdOps = append(etcdOps, clientv3.OpDelete(e.etcdKey(op.StorageId, op.Key)))
+		default:
+			return fmt.Errorf("unknown operation type %d", op.Type)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	_, err := e.client.Txn(ctx).Then(etcdOps...).Commit()
+	return err
+}
+
+func (e *EtcdStore) Close() error {
+	return e.client.Close()
+}
+
+func (e *EtcdStore) etcdKey(storageId, key string) string {
+	return e.prefix + storageId + "/" + key
+}
+
+func LoadEtcdConfig(cfgPath string) (EtcdConfig, error) {
+	if cfgPath != "" {
+		etcdConfigFilePath = cfgPath
+	}
+	data, err := os.ReadFile(etcdConfigFilePath)
+	if err != nil {
+		return EtcdConfig{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	var config EtcdConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return EtcdConfig{}, fmt.Errorf("error parsing YAML: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,189 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FILE_LOCK_NAME   = ".lock"
+	FILE_TEMP_PREFIX = ".tmp-"
+	// FILE_NAME_MAX is the longest file name most filesystems accept
+	FILE_NAME_MAX = 255
+)
+
+var (
+	fileConfigFilePath = "/var/db/file_config.yaml"
+)
+
+type FileConfig struct {
+	Path string
+}
+
+// FileStore keeps every entry in its own file below a data directory. Each
+// storage id is a sub-directory and keys are escaped into flat file names.
+type FileStore struct {
+	dir  string
+	lock *fileLock
+}
+
+func NewFileStore(cfg FileConfig) (*FileStore, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("file store path is required")
+	}
+	dir, err := filepath.Abs(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	lock, err := acquireFileLock(filepath.Join(dir, FILE_LOCK_NAME))
+	if err != nil {
+		return nil, fmt.Errorf("data directory %s is in use by another process: %w", dir, err)
+	}
+	return &FileStore{dir: dir, lock: lock}, nil
+}
+
+func (f *FileStore) Ping() error {
+	_, err := os.Stat(f.dir)
+	return err
+}
+
+// Store fails with ErrKeyTooLong when the escaped key does not fit in a
+// file name.
+func (f *FileStore) Store(storageId, key string, value []byte) error {
+	name := escapeFileName(key)
+	if len(name) > FILE_NAME_MAX {
+		return fmt.Errorf("%w for the file store: %d bytes escaped, at most %d", ErrKeyTooLong, len(name), FILE_NAME_MAX)
+	}
+	dir := f.storageDir(storageId)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, name), value)
+}
+
+func (f *FileStore) Retrieve(storageId, key string) ([]byte, error) {
+	value, err := os.ReadFile(filepath.Join(f.storageDir(storageId), escapeFileName(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrKeyNotFound
+	}
+	return value, err
+}
+
+func (f *FileStore) Delete(storageId, key string) error {
+	dir := f.storageDir(storageId)
+	err := os.Remove(filepath.Join(dir, escapeFileName(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func (f *FileStore) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	entries, err := os.ReadDir(f.storageDir(storageId))
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		// skip in-flight temp files
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		key, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	children, next := listChildren(keys, prefix, cursor, limit)
+	return children, next, nil
+}
+
+func (f *FileStore) Close() error {
+	return f.lock.release()
+}
+
+func (f *FileStore) storageDir(storageId string) string {
+	return filepath.Join(f.dir, escapeFileName(storageId))
+}
+
+// escapeFileName maps a key to a single file name. Slashes are escaped and a
+// leading dot is encoded so keys never collide with "." , ".." or temp files.
+func escapeFileName(key string) string {
+	name := url.PathEscape(key)
+	if strings.HasPrefix(name, ".") {
+		name = "%2E" + name[1:]
+	}
+	return name
+}
+
+// writeFileAtomic writes data to a temp file, fsyncs it and renames it into
+// place, so readers see either the old or the new value.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, FILE_TEMP_PREFIX+"*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpName, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir persists directory entries after a rename or removal.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func LoadFileConfig(cfgPath string) (FileConfig, error) {
+	if cfgPath != "" {
+		fileConfigFilePath = cfgPath
+	}
+	data, err := os.ReadFile(fileConfigFilePath)
+	if err != nil {
+		return FileConfig{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	var config FileConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return FileConfig{}, fmt.Errorf("error parsing YAML: %w", err)
+	}
+	return config, nil
+}
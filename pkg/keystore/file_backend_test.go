@@ -0,0 +1,125 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T, dir string) *FileStore {
+	t.Helper()
+	store, err := NewFileStore(FileConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestFileStore(t *testing.T) {
+	store := newTestFileStore(t, t.TempDir())
+	keys := []string{"app/db", "app/nested/one", ".hidden", "..", "with space", "100%"}
+	for _, key := range keys {
+		if err := store.Store(SECRETS_STORAGE, key, []byte("value of "+key)); err != nil {
+			t.Fatalf("Store %q: %v", key, err)
+		}
+	}
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			value, err := store.Retrieve(SECRETS_STORAGE, key)
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			if want := []byte("value of " + key); !bytes.Equal(value, want) {
+				t.Fatalf("got %q, want %q", value, want)
+			}
+			if _, err = store.Retrieve(SYSTEM_STORAGE, key); !errors.Is(err, ErrKeyNotFound) {
+				t.Fatalf("other storage id: got %v, want %v", err, ErrKeyNotFound)
+			}
+		})
+	}
+
+	children, next, err := store.List(SECRETS_STORAGE, "", "", 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"..", ".hidden", "100%", "app/", "with space"}
+	if !reflect.DeepEqual(children, want) || next != "" {
+		t.Fatalf("List: got %q, %q, want %q", children, next, want)
+	}
+
+	if err = store.Delete(SECRETS_STORAGE, "app/db"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = store.Retrieve(SECRETS_STORAGE, "app/db"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Retrieve after Delete: got %v, want %v", err, ErrKeyNotFound)
+	}
+	if err = store.Delete(SECRETS_STORAGE, "app/db"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestFileStoreListSkipsTempFiles(t *testing.T) {
+	store := newTestFileStore(t, t.TempDir())
+	if err := store.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	// a temp file left behind by a crash mid-write
+	tmp, err := os.CreateTemp(store.storageDir(SECRETS_STORAGE), FILE_TEMP_PREFIX+"*")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	tmp.Close()
+	children, _, err := store.List(SECRETS_STORAGE, "", "", 0)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if want := []string{"app/"}; !reflect.DeepEqual(children, want) {
+		t.Fatalf("got %q, want %q", children, want)
+	}
+}
+
+func TestFileStoreLock(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(FileConfig{Path: dir})
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	if _, err = NewFileStore(FileConfig{Path: dir}); err == nil {
+		t.Fatalf("second NewFileStore on a locked directory succeeded")
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	reopened := newTestFileStore(t, dir)
+	if err = reopened.Ping(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+func TestFileStoreKeyTooLong(t *testing.T) {
+	fs := newTestFileStore(t, t.TempDir())
+	tests := []struct {
+		name string
+		key  string
+		err  error
+	}{
+		{"longest key", strings.Repeat("a", FILE_NAME_MAX), nil},
+		{"one byte over", strings.Repeat("a", FILE_NAME_MAX+1), ErrKeyTooLong},
+		{"too long once escaped", strings.Repeat("a/", FILE_NAME_MAX/3), ErrKeyTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fs.Store(SECRETS_STORAGE, tt.key, []byte("value"))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Store: got %v, want %v", err, tt.err)
+			}
+			if _, err = fs.Retrieve(SECRETS_STORAGE, tt.key); (err == nil) != (tt.err == nil) {
+				t.Fatalf("Retrieve: %v", err)
+			}
+		})
+	}
+}
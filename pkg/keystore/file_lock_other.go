@@ -0,0 +1,29 @@
+//go:build !unix
+
+package keystore
+
+import (
+	"fmt"
+	"os"
+)
+
+// fileLock falls back to an exclusively created lock file. A stale file
+// left by a crashed process has to be removed by hand.
+type fileLock struct {
+	path string
+	file *os.File
+}
+
+func acquireFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	return &fileLock{path: path, file: file}, nil
+}
+
+func (l *fileLock) release() error {
+	l.file.Close()
+	return os.Remove(l.path)
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package keystore
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileLock is an exclusive advisory lock held for the lifetime of the
+// process. The kernel drops it if the process dies.
+type fileLock struct {
+	file *os.File
+}
+
+func acquireFileLock(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &fileLock{file: file}, nil
+}
+
+func (l *fileLock) release() error {
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
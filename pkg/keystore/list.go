@@ -0,0 +1,120 @@
+package keystore
+
+import (
+	"sort"
+	"strings"
+)
+
+// WALK_PAGE_SIZE is the number of entries Walk lists at a time.
+const WALK_PAGE_SIZE = 1000
+
+// Walk calls fn for every key of a storage id that sorts after the key after,
+// in lexical byte order, descending into folders as it goes. Pass an empty
+// after to visit every key.
+func Walk(be BackendKeyStore, storageId, after string, fn func(key string) error) error {
+	return walk(be, storageId, "", after, fn)
+}
+
+func walk(be BackendKeyStore, storageId, prefix, after string, fn func(key string) error) error {
+	cursor := ""
+	for {
+		children, next, err := be.List(storageId, prefix, cursor, WALK_PAGE_SIZE)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			key := prefix + child
+			if strings.HasSuffix(child, "/") {
+				// every key in the folder sorts before after unless after is
+				// inside the folder
+				if key <= after && !strings.HasPrefix(after, key) {
+					continue
+				}
+				if err = walk(be, storageId, key, after, fn); err != nil {
+					return err
+				}
+				continue
+			}
+			if key <= after {
+				continue
+			}
+			if err = fn(key); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// listChildren applies the List contract of BackendKeyStore to the full set
+// of keys of a storage id, for backends without native folder listing.
+func listChildren(keys []string, prefix, cursor string, limit int) ([]string, string) {
+	seen := make(map[string]bool)
+	children := make([]string, 0)
+	for _, key := range keys {
+		if len(key) <= len(prefix) || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		child := key[len(prefix):]
+		if i := strings.Index(child, "/"); i >= 0 {
+			child = child[:i+1]
+		}
+		if child <= cursor || seen[child] {
+			continue
+		}
+		seen[child] = true
+		children = append(children, child)
+	}
+	sort.Strings(children)
+
+	next := ""
+	if limit > 0 && len(children) > limit {
+		children = children[:limit]
+		next = children[limit-1]
+	}
+	return children, next
+}
+
+// sortedChildren applies the List contract of BackendKeyStore to keys read
+// in lexical byte order, so backends that list keys sorted can stop reading
+// once the page is full instead of reading the whole storage id.
+type sortedChildren struct {
+	prefix   string
+	cursor   string
+	limit    int
+	children []string
+}
+
+func newSortedChildren(prefix, cursor string, limit int) *sortedChildren {
+	return &sortedChildren{prefix: prefix, cursor: cursor, limit: limit, children: make([]string, 0)}
+}
+
+// add folds key into the children and reports whether the page is full.
+// Keys must be added in lexical byte order.
+func (c *sortedChildren) add(key string) bool {
+	if len(key) <= len(c.prefix) || !strings.HasPrefix(key, c.prefix) {
+		return false
+	}
+	child := key[len(c.prefix):]
+	if i := strings.Index(child, "/"); i >= 0 {
+		child = child[:i+1]
+	}
+	// the keys of a folder are added one after the other
+	if child <= c.cursor || (len(c.children) > 0 && c.children[len(c.children)-1] == child) {
+		return false
+	}
+	c.children = append(c.children, child)
+	// one child past the limit tells whether there is a next page
+	return c.limit > 0 && len(c.children) > c.limit
+}
+
+// page returns the children collected and the cursor of the next page.
+func (c *sortedChildren) page() ([]string, string) {
+	if c.limit > 0 && len(c.children) > c.limit {
+		return c.children[:c.limit], c.children[c.limit-1]
+	}
+	return c.children, ""
+}
@@ -0,0 +1,148 @@
+package keystore
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+var testKeys = []string{
+	"app/db",
+	"app/api",
+	"app/nested/one",
+	"app/nested/two",
+	"billing",
+	"ci/token",
+	"zeta",
+}
+
+func TestListChildren(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		cursor   string
+		limit    int
+		children []string
+		next     string
+	}{
+		{"root", "", "", 0, []string{"app/", "billing", "ci/", "zeta"}, ""},
+		{"folder", "app/", "", 0, []string{"api", "db", "nested/"}, ""},
+		{"nested folder", "app/nested/", "", 0, []string{"one", "two"}, ""},
+		{"prefix is not a folder", "app/d", "", 0, []string{"b"}, ""},
+		{"missing folder", "none/", "", 0, []string{}, ""},
+		{"first page", "", "", 2, []string{"app/", "billing"}, "billing"},
+		{"second page", "", "billing", 2, []string{"ci/", "zeta"}, ""},
+		{"page ends on last child", "", "", 4, []string{"app/", "billing", "ci/", "zeta"}, ""},
+		{"cursor inside folder listing", "app/", "api", 1, []string{"db"}, "db"},
+		{"cursor after last child", "", "zeta", 2, []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			children, next := listChildren(testKeys, tt.prefix, tt.cursor, tt.limit)
+			if !reflect.DeepEqual(children, tt.children) {
+				t.Fatalf("children: got %q, want %q", children, tt.children)
+			}
+			if next != tt.next {
+				t.Fatalf("next: got %q, want %q", next, tt.next)
+			}
+		})
+	}
+}
+
+func TestListChildrenPages(t *testing.T) {
+	for limit := 1; limit <= len(testKeys); limit++ {
+		var all []string
+		cursor := ""
+		for {
+			children, next := listChildren(testKeys, "", cursor, limit)
+			if len(children) > limit {
+				t.Fatalf("limit %d: got page of %d", limit, len(children))
+			}
+			all = append(all, children...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		want := []string{"app/", "billing", "ci/", "zeta"}
+		if !reflect.DeepEqual(all, want) {
+			t.Fatalf("limit %d: got %q, want %q", limit, all, want)
+		}
+	}
+}
+
+func TestSortedChildren(t *testing.T) {
+	sorted := append([]string(nil), testKeys...)
+	sort.Strings(sorted)
+	tests := []struct {
+		prefix string
+		cursor string
+		limit  int
+		read   int
+	}{
+		{"", "", 0, 7},
+		{"", "", 2, 6},
+		{"", "billing", 2, 7},
+		{"", "app/", 1, 6},
+		{"app/", "", 1, 2},
+		{"app/", "api", 1, 3},
+		{"app/nested/", "", 0, 7},
+		{"none/", "", 2, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix+"|"+tt.cursor, func(t *testing.T) {
+			c := newSortedChildren(tt.prefix, tt.cursor, tt.limit)
+			read := 0
+			for _, key := range sorted {
+				read++
+				if c.add(key) {
+					break
+				}
+			}
+			children, next := c.page()
+			// the same page as a full scan, without reading past it
+			wantChildren, wantNext := listChildren(testKeys, tt.prefix, tt.cursor, tt.limit)
+			if !reflect.DeepEqual(children, wantChildren) || next != wantNext {
+				t.Fatalf("got %q, %q, want %q, %q", children, next, wantChildren, wantNext)
+			}
+			if read != tt.read {
+				t.Fatalf("read %d keys, want %d", read, tt.read)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	store := NewMemoryStore()
+	for _, key := range testKeys {
+		if err := store.Store(SECRETS_STORAGE, key, []byte(key)); err != nil {
+			t.Fatalf("Store: %v", err)
+		}
+	}
+	tests := []struct {
+		name  string
+		after string
+		keys  []string
+	}{
+		{"every key", "", []string{"app/api", "app/db", "app/nested/one", "app/nested/two", "billing", "ci/token", "zeta"}},
+		{"after a key", "app/db", []string{"app/nested/one", "app/nested/two", "billing", "ci/token", "zeta"}},
+		{"after a nested key", "app/nested/one", []string{"app/nested/two", "billing", "ci/token", "zeta"}},
+		{"after a folder", "app/", []string{"app/api", "app/db", "app/nested/one", "app/nested/two", "billing", "ci/token", "zeta"}},
+		{"after the last key", "zeta", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			err := Walk(store, SECRETS_STORAGE, tt.after, func(key string) error {
+				keys = append(keys, key)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Walk: %v", err)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Fatalf("got %q, want %q", keys, tt.keys)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package keystore
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemoryStore keeps every entry in process memory. It is meant for tests and
+// dev mode; all data is lost when the process exits.
+type MemoryStore struct {
+	l    sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data: make(map[string]map[string][]byte),
+	}
+}
+
+func (m *MemoryStore) Ping() error {
+	return nil
+}
+
+func (m *MemoryStore) Store(storageId, key string, value []byte) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+	entries, ok := m.data[storageId]
+	if !ok {
+		entries = make(map[string][]byte)
+		m.data[storageId] = entries
+	}
+	entries[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *MemoryStore) Retrieve(storageId, key string) ([]byte, error) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	value, ok := m.data[storageId][key]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (m *MemoryStore) Delete(storageId, key string) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+	delete(m.data[storageId], key)
+	return nil
+}
+
+func (m *MemoryStore) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	m.l.RLock()
+	defer m.l.RUnlock()
+	keys := make([]string, 0, len(m.data[storageId]))
+	for key := range m.data[storageId] {
+		keys = append(keys, key)
+	}
+	children, next := listChildren(keys, prefix, cursor, limit)
+	return children, next, nil
+}
+
+// Transaction applies ops under a single lock, validating them first so a
+// bad operation leaves the store untouched.
+func (m *MemoryStore) Transaction(ops []Operation) error {
+	for _, op := range ops {
+		if op.Type != PUT_OPERATION && op.Type != DELETE_OPERATION {
+			return fmt.Errorf("unknown operation type %d", op.Type)
+		}
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	for _, op := range ops {
+		switch op.Type {
+		case PUT_OPERATION:
+			entries, ok := m.data[op.StorageId]
+			if !ok {
+				entries = make(map[string][]byte)
+				m.data[op.StorageId] = entries
+			}
+			entries[op.Key] = append([]byte(nil), op.Value...)
+		case DELETE_OPERATION:
+			delete(m.data[op.StorageId], op.Key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
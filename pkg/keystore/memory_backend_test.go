@@ -0,0 +1,124 @@
+package keystore
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	if err := store.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	tests := []struct {
+		name      string
+		storageId string
+		key       string
+		value     []byte
+		err       error
+	}{
+		{"stored key", SECRETS_STORAGE, "app/db", []byte("hunter2"), nil},
+		{"missing key", SECRETS_STORAGE, "app/api", nil, ErrKeyNotFound},
+		{"other storage id", SYSTEM_STORAGE, "app/db", nil, ErrKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := store.Retrieve(tt.storageId, tt.key)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if !bytes.Equal(value, tt.value) {
+				t.Fatalf("got %q, want %q", value, tt.value)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreCopies(t *testing.T) {
+	store := NewMemoryStore()
+	value := []byte("hunter2")
+	if err := store.Store(SECRETS_STORAGE, "app/db", value); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+	value[0] = 'X'
+	got, err := store.Retrieve(SECRETS_STORAGE, "app/db")
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	got[1] = 'X'
+	again, err := store.Retrieve(SECRETS_STORAGE, "app/db")
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if string(again) != "hunter2" {
+		t.Fatalf("stored value was changed through a caller's slice: %q", again)
+	}
+}
+
+func TestMemoryStoreTransaction(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Operation
+		err  bool
+		want map[string]string
+	}{
+		{
+			name: "puts and deletes",
+			ops: []Operation{
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/api", Value: []byte("swordfish")},
+				{Type: DELETE_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db"},
+			},
+			want: map[string]string{"app/api": "swordfish"},
+		},
+		{
+			name: "later operations win",
+			ops: []Operation{
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db", Value: []byte("first")},
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db", Value: []byte("second")},
+			},
+			want: map[string]string{"app/db": "second"},
+		},
+		{
+			name: "unknown operation leaves the store untouched",
+			ops: []Operation{
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/api", Value: []byte("swordfish")},
+				{Type: OperationType(42), StorageId: SECRETS_STORAGE, Key: "app/db"},
+			},
+			err:  true,
+			want: map[string]string{"app/db": "hunter2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			if err := store.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			err := store.Transaction(tt.ops)
+			if (err != nil) != tt.err {
+				t.Fatalf("Transaction: got %v, want error %v", err, tt.err)
+			}
+			children, _, err := store.List(SECRETS_STORAGE, "app/", "", 0)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			got := make(map[string]string)
+			for _, child := range children {
+				value, err := store.Retrieve(SECRETS_STORAGE, "app/"+child)
+				if err != nil {
+					t.Fatalf("Retrieve: %v", err)
+				}
+				got["app/"+child] = string(value)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
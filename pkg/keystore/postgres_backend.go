@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
+	"github.com/lib/pq"
 	"gopkg.in/yaml.v2"
-	_ "github.com/lib/pq"
 )
 
 var (
@@ -20,10 +21,12 @@ type PostgresConfig struct {
 	User     string
 	Password string
 	DBName   string
+	SSLMode  string
 }
 
 type PostgresStore struct {
-	db *sql.DB
+	db      *sql.DB
+	connStr string
 }
 
 func NewPostgresStore(connCfg PostgresConfig) (*PostgresStore, error) {
@@ -32,7 +35,18 @@ func NewPostgresStore(connCfg PostgresConfig) (*PostgresStore, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &PostgresStore{db: db}, nil
+	return &PostgresStore{db: db, connStr: connStr}, nil
+}
+
+// DB returns the connection pool of the store, so the state database can
+// share it.
+func (p *PostgresStore) DB() *sql.DB {
+	return p.db
+}
+
+// ConnString returns the connection string the store was opened with.
+func (p *PostgresStore) ConnString() string {
+	return p.connStr
 }
 
 func (p *PostgresStore) Ping() error {
@@ -40,21 +54,98 @@ func (p *PostgresStore) Ping() error {
 }
 
 func (p *PostgresStore) Store(table, key string, value []byte) error {
-	_, err := p.db.Exec("INSERT INTO $3 (key, value) VALUES ($1, $2) ON CONFLICT (key) DO UPDATE SET value = $2", key, value, table)
+	query := fmt.Sprintf(`INSERT INTO %s (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = now()`, quoteIdentifier(table))
+	_, err := p.db.Exec(query, key, value)
 	return err
 }
 
 func (p *PostgresStore) Retrieve(table, key string) ([]byte, error) {
 	var value []byte
-	err := p.db.QueryRow("SELECT value FROM $2 WHERE key = $1", key, table).Scan(&value)
+	query := fmt.Sprintf("SELECT value FROM %s WHERE key = $1", quoteIdentifier(table))
+	err := p.db.QueryRow(query, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, ErrKeyNotFound
+	}
 	return value, err
 }
 
 func (p *PostgresStore) Delete(table, key string) error {
-	_, err := p.db.Exec("DELETE FROM $2 WHERE key = $1", key, table)
+	query := fmt.Sprintf("DELETE FROM %s WHERE key = $1", quoteIdentifier(table))
+	_, err := p.db.Exec(query, key)
 	return err
 }
 
+func (p *PostgresStore) List(table, prefix, cursor string, limit int) ([]string, string, error) {
+	// fold everything below the next "/" into a single folder entry and
+	// compare bytewise so pages are stable regardless of the database collation
+	query := fmt.Sprintf(`SELECT child FROM (
+			SELECT DISTINCT CASE WHEN strpos(rest, '/') > 0 THEN left(rest, strpos(rest, '/')) ELSE rest END AS child
+			FROM (SELECT substr(key, char_length($1) + 1) AS rest FROM %s
+				WHERE left(key, char_length($1)) = $1 AND char_length(key) > char_length($1)) AS k
+		) AS c
+		WHERE child COLLATE "C" > $2
+		ORDER BY child COLLATE "C"
+		LIMIT $3`, quoteIdentifier(table))
+	var queryLimit interface{}
+	if limit > 0 {
+		queryLimit = limit + 1
+	}
+	rows, err := p.db.Query(query, prefix, cursor, queryLimit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err = rows.Scan(&key); err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+		next = keys[limit-1]
+	}
+	return keys, next, nil
+}
+
+// Transaction applies ops in a single SQL transaction.
+func (p *PostgresStore) Transaction(ops []Operation) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, op := range ops {
+		switch op.Type {
+		case PUT_OPERATION:
+			query := fmt.Sprintf(`INSERT INTO %s (key, value) VALUES ($1, $2)
+				ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = now()`, quoteIdentifier(op.StorageId))
+			_, err = tx.Exec(query, op.Key, op.Value)
+		case DELETE_OPERATION:
+			query := fmt.Sprintf("DELETE FROM %s WHERE key = $1", quoteIdentifier(op.StorageId))
+			_, err = tx.Exec(query, op.Key)
+		default:
+			err = fmt.Errorf("unknown operation type %d", op.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
 func LoadPostgresConfig(cfgPath string) (PostgresConfig, error) {
 	// Read the YAML file
 	if cfgPath != "" {
@@ -71,10 +162,32 @@ func LoadPostgresConfig(cfgPath string) (PostgresConfig, error) {
 	if err != nil {
 		return PostgresConfig{}, fmt.Errorf("error parsing YAML: %w", err)
 	}
+	if config.SSLMode == "" {
+		config.SSLMode = "disable"
+	}
 
 	return config, nil
 }
 
 func GetConnectionStringFromConfig(connCfg PostgresConfig) string {
-	return "host=" + connCfg.Host + " port=" + strconv.Itoa(connCfg.Port) + " user=" + connCfg.User + " password=" + connCfg.Password + " dbname=" + connCfg.DBName + " sslmode=disable"
+	return "host=" + quoteConnValue(connCfg.Host) +
+		" port=" + strconv.Itoa(connCfg.Port) +
+		" user=" + quoteConnValue(connCfg.User) +
+		" password=" + quoteConnValue(connCfg.Password) +
+		" dbname=" + quoteConnValue(connCfg.DBName) +
+		" sslmode=" + quoteConnValue(connCfg.SSLMode)
+}
+
+// quoteConnValue quotes a connection string value so that spaces and quotes
+// in passwords do not break parsing.
+func quoteConnValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+// quoteIdentifier quotes a storage id for use as a table name. Storage ids
+// cannot be bound as query parameters.
+func quoteIdentifier(name string) string {
+	return pq.QuoteIdentifier(name)
 }
@@ -0,0 +1,127 @@
+package keystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/skriptvalley/keyhouse/db"
+)
+
+const (
+	MIGRATIONS_TABLE = "schema_migrations"
+	// arbitrary key for the advisory lock serializing migrations between replicas
+	MIGRATIONS_LOCK_ID = 7401
+)
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+type migration struct {
+	version int64
+	name    string
+	sql     string
+}
+
+// loadMigrations returns the embedded up migrations ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(db.Migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	var migrations []migration
+	seen := make(map[int64]string)
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d in %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+		content, err := fs.ReadFile(db.Migrations, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: match[2], sql: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Migrate brings the database schema up to the latest embedded version. It
+// fails if the database was migrated by a newer keyhouse binary.
+func (p *PostgresStore) Migrate() error {
+	ctx := context.Background()
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	var latest int64
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].version
+	}
+
+	// advisory locks are bound to a session, so keep a single connection
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", MIGRATIONS_LOCK_ID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", MIGRATIONS_LOCK_ID)
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`, quoteIdentifier(MIGRATIONS_TABLE)))
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var current int64
+	err = conn.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", quoteIdentifier(MIGRATIONS_TABLE))).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than version %d supported by this binary", current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err = applyMigration(ctx, conn, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, m.sql); err != nil {
+		return fmt.Errorf("failed to apply migration %d_%s: %w", m.version, m.name, err)
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", quoteIdentifier(MIGRATIONS_TABLE)), m.version, m.name)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,81 @@
+package keystore
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/db"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatalf("no migrations found")
+	}
+	for i, m := range migrations {
+		t.Run(m.name, func(t *testing.T) {
+			// versions are applied in order, so a gap means a missing file
+			if m.version != int64(i+1) {
+				t.Fatalf("got version %d, want %d", m.version, i+1)
+			}
+			if strings.TrimSpace(m.sql) == "" {
+				t.Fatalf("migration is empty")
+			}
+			matches, err := fs.Glob(db.Migrations, "migrations/*_"+m.name+".down.sql")
+			if err != nil || len(matches) != 1 {
+				t.Fatalf("got down migrations %v, want one", matches)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"secrets", `"secrets"`},
+		{"secret_versions", `"secret_versions"`},
+		{`x"; DROP TABLE secrets; --`, `"x""; DROP TABLE secrets; --"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteIdentifier(tt.name); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionString(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"plain", "secret", `password='secret'`},
+		{"spaces", "two words", `password='two words'`},
+		{"quotes", `it's`, `password='it\'s'`},
+		{"backslashes", `a\b`, `password='a\\b'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := GetConnectionStringFromConfig(PostgresConfig{
+				Host:     "db",
+				Port:     5432,
+				User:     "keyhouse",
+				Password: tt.password,
+				DBName:   "keyhouse",
+				SSLMode:  "disable",
+			})
+			want := `host='db' port=5432 user='keyhouse' ` + tt.want + ` dbname='keyhouse' sslmode='disable'`
+			if conn != want {
+				t.Fatalf("got %s, want %s", conn, want)
+			}
+		})
+	}
+}
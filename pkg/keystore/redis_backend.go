@@ -0,0 +1,158 @@
+package keystore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	redis "github.com/go-redis/redis/v8"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// REDIS_STATE_DB is the logical db used by statemanager.RedisDB, which
+	// the keystore must not share
+	REDIS_STATE_DB      = 0
+	REDIS_DEFAULT_DB    = 1
+	REDIS_DEFAULT_SPACE = "keyhouse"
+	REDIS_SCAN_COUNT    = 1000
+)
+
+var (
+	redisConfigFilePath = "/var/db/redis_config.yaml"
+)
+
+type RedisConfig struct {
+	Host     string
+	Port     int
+	Password string
+	DB       *int
+	// Prefix namespaces every key, as <prefix>:<storage id>:<key>
+	Prefix string
+}
+
+// RedisStore keeps every entry as a plain redis string. Storage ids map to
+// key namespaces.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(cfg RedisConfig) (*RedisStore, error) {
+	db := REDIS_DEFAULT_DB
+	if cfg.DB != nil {
+		db = *cfg.DB
+	}
+	if db == REDIS_STATE_DB {
+		return nil, fmt.Errorf("redis db %d is reserved for the vault state, choose another db", REDIS_STATE_DB)
+	}
+	prefix := cfg.Prefix
+	if prefix == "" {
+		prefix = REDIS_DEFAULT_SPACE
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Host + ":" + strconv.Itoa(cfg.Port),
+		Password: cfg.Password,
+		DB:       db,
+	})
+	return &RedisStore{client: client, prefix: prefix}, nil
+}
+
+func (r *RedisStore) Ping() error {
+	return r.client.Ping(context.Background()).Err()
+}
+
+func (r *RedisStore) Store(storageId, key string, value []byte) error {
+	return r.client.Set(context.Background(), r.redisKey(storageId, key), value, 0).Err()
+}
+
+func (r *RedisStore) Retrieve(storageId, key string) ([]byte, error) {
+	value, err := r.client.Get(context.Background(), r.redisKey(storageId, key)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrKeyNotFound
+	}
+	return value, err
+}
+
+func (r *RedisStore) Delete(storageId, key string) error {
+	return r.client.Del(context.Background(), r.redisKey(storageId, key)).Err()
+}
+
+// List scans every key below prefix on each call, since SCAN returns keys
+// in no particular order and the page can only be cut once all of them are
+// sorted. A page costs a scan of the whole prefix however small limit is, so
+// walking a storage id of n keys reads about n*n/WALK_PAGE_SIZE keys. That
+// is fine for the secrets a vault holds but not for bulk data.
+func (r *RedisStore) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	ctx := context.Background()
+	namespace := r.redisKey(storageId, "")
+	match := escapeGlob(namespace+prefix) + "*"
+
+	var keys []string
+	iter := r.client.Scan(ctx, 0, match, REDIS_SCAN_COUNT).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), namespace))
+	}
+	if err := iter.Err(); err != nil {
+		return nil, "", err
+	}
+	children, next := listChildren(keys, prefix, cursor, limit)
+	return children, next, nil
+}
+
+// Transaction applies ops in a MULTI/EXEC block.
+func (r *RedisStore) Transaction(ops []Operation) error {
+	_, err := r.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for _, op := range ops {
+			switch op.Type {
+			case PUT_OPERATION:
+				pipe.Set(context.Background(), r.redisKey(op.StorageId, op.Key), op.Value, 0)
+			case DELETE_OPERATION:
+				pipe.Del(context.Background(), r.redisKey(op.StorageId, op.Key))
+			default:
+				return fmt.Errorf("unknown operation type %d", op.Type)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisStore) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisStore) redisKey(storageId, key string) string {
+	return r.prefix + ":" + storageId + ":" + key
+}
+
+// escapeGlob escapes the characters that SCAN MATCH treats as a pattern.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func LoadRedisConfig(cfgPath string) (RedisConfig, error) {
+	if cfgPath != "" {
+		redisConfigFilePath = cfgPath
+	}
+	data, err := os.ReadFile(redisConfigFilePath)
+	if err != nil {
+		return RedisConfig{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	var config RedisConfig
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return RedisConfig{}, fmt.Errorf("error parsing YAML: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,176 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	s3ConfigFilePath = "/var/db/s3_config.yaml"
+)
+
+type S3Config struct {
+	Endpoint     string `yaml:"endpoint"`
+	Region       string `yaml:"region"`
+	Bucket       string `yaml:"bucket"`
+	Prefix       string `yaml:"prefix"`
+	AccessKey    string `yaml:"access_key"`
+	SecretKey    string `yaml:"secret_key"`
+	SessionToken string `yaml:"session_token"`
+	UseSSL       bool   `yaml:"use_ssl"`
+	// PathStyle addresses buckets as endpoint/bucket, as MinIO expects
+	PathStyle bool `yaml:"path_style"`
+	// CreateBucket creates the bucket on startup if it does not exist
+	CreateBucket bool `yaml:"create_bucket"`
+	// Versioning enables object versioning on the bucket on startup
+	Versioning bool `yaml:"versioning"`
+}
+
+// S3Store keeps every entry as an object named <prefix><storage id>/<key>
+// in an S3-compatible bucket.
+type S3Store struct {
+	client *minio.Client
+	cfg    S3Config
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client: %w", err)
+	}
+	if cfg.Prefix != "" && !strings.HasSuffix(cfg.Prefix, "/") {
+		cfg.Prefix += "/"
+	}
+	return &S3Store{client: client, cfg: cfg}, nil
+}
+
+func (s *S3Store) Ping() error {
+	_, err := s.client.BucketExists(context.Background(), s.cfg.Bucket)
+	return err
+}
+
+// Migrate prepares the bucket according to the store config.
+func (s *S3Store) Migrate() error {
+	ctx := context.Background()
+	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if !s.cfg.CreateBucket {
+			return fmt.Errorf("bucket %s does not exist", s.cfg.Bucket)
+		}
+		if err = s.client.MakeBucket(ctx, s.cfg.Bucket, minio.MakeBucketOptions{Region: s.cfg.Region}); err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", s.cfg.Bucket, err)
+		}
+	}
+	if s.cfg.Versioning {
+		if err = s.client.EnableVersioning(ctx, s.cfg.Bucket); err != nil {
+			return fmt.Errorf("failed to enable versioning on bucket %s: %w", s.cfg.Bucket, err)
+		}
+	}
+	return nil
+}
+
+func (s *S3Store) Store(storageId, key string, value []byte) error {
+	_, err := s.client.PutObject(context.Background(), s.cfg.Bucket, s.objectName(storageId, key),
+		bytes.NewReader(value), int64(len(value)), minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (s *S3Store) Retrieve(storageId, key string) ([]byte, error) {
+	obj, err := s.client.GetObject(context.Background(), s.cfg.Bucket, s.objectName(storageId, key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	defer obj.Close()
+	value, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return value, nil
+}
+
+func (s *S3Store) Delete(storageId, key string) error {
+	return s.client.RemoveObject(context.Background(), s.cfg.Bucket, s.objectName(storageId, key), minio.RemoveObjectOptions{})
+}
+
+// List reads the keys below prefix in lexical order from the cursor on and
+// stops once the page is full. A page also reads the keys of the folders it
+// passes, so folders are not folded by the server with a delimiter: its
+// pages put objects before common prefixes, which would break the order.
+func (s *S3Store) List(storageId, prefix, cursor string, limit int) ([]string, string, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// stops the listing when the page is full before the last object
+	defer cancel()
+	base := s.objectName(storageId, "")
+	opts := minio.ListObjectsOptions{
+		Prefix:    base + prefix,
+		Recursive: true,
+	}
+	if cursor != "" {
+		opts.StartAfter = base + prefix + cursor
+	}
+	children := newSortedChildren(prefix, cursor, limit)
+	for obj := range s.client.ListObjects(ctx, s.cfg.Bucket, opts) {
+		if obj.Err != nil {
+			return nil, "", obj.Err
+		}
+		if children.add(strings.TrimPrefix(obj.Key, base)) {
+			break
+		}
+	}
+	page, next := children.page()
+	return page, next, nil
+}
+
+func (s *S3Store) Close() error {
+	return nil
+}
+
+func (s *S3Store) objectName(storageId, key string) string {
+	return s.cfg.Prefix + storageId + "/" + key
+}
+
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrKeyNotFound
+	}
+	return err
+}
+
+func LoadS3Config(cfgPath string) (S3Config, error) {
+	if cfgPath != "" {
+		s3ConfigFilePath = cfgPath
+	}
+	data, err := os.ReadFile(s3ConfigFilePath)
+	if err != nil {
+		return S3Config{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	var config S3Config
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return S3Config{}, fmt.Errorf("error parsing YAML: %w", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,47 @@
+package keystore
+
+import "fmt"
+
+type OperationType int
+
+const (
+	PUT_OPERATION OperationType = iota
+	DELETE_OPERATION
+)
+
+// Operation is a single write applied as part of a transaction.
+type Operation struct {
+	Type      OperationType
+	StorageId string
+	Key       string
+	Value     []byte
+}
+
+// Transactional is implemented by backends that can apply a batch of
+// operations atomically: either every operation is applied or none is.
+type Transactional interface {
+	Transaction(ops []Operation) error
+}
+
+// ApplyOperations applies ops atomically when the backend supports
+// transactions, and one by one in order otherwise.
+func ApplyOperations(be BackendKeyStore, ops []Operation) error {
+	if txn, ok := be.(Transactional); ok {
+		return txn.Transaction(ops)
+	}
+	for _, op := range ops {
+		var err error
+		switch op.Type {
+		case PUT_OPERATION:
+			err = be.Store(op.StorageId, op.Key, op.Value)
+		case DELETE_OPERATION:
+			err = be.Delete(op.StorageId, op.Key)
+		default:
+			return fmt.Errorf("unknown operation type %d", op.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
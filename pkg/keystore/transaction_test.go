@@ -0,0 +1,60 @@
+package keystore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyOperations(t *testing.T) {
+	tests := []struct {
+		name          string
+		backend       func(t *testing.T) BackendKeyStore
+		transactional bool
+	}{
+		{"transactional backend", func(t *testing.T) BackendKeyStore { return NewMemoryStore() }, true},
+		{"one by one", func(t *testing.T) BackendKeyStore { return newTestFileStore(t, t.TempDir()) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			be := tt.backend(t)
+			if _, ok := be.(Transactional); ok != tt.transactional {
+				t.Fatalf("backend is transactional: %v, want %v", ok, tt.transactional)
+			}
+			if _, ok := NewBarrier(be).(Transactional); ok != tt.transactional {
+				t.Fatalf("barrier is transactional: %v, want %v", ok, tt.transactional)
+			}
+			if err := be.Store(SECRETS_STORAGE, "app/db", []byte("hunter2")); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+			err := ApplyOperations(be, []Operation{
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/api", Value: []byte("first")},
+				{Type: DELETE_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/db"},
+				{Type: PUT_OPERATION, StorageId: SECRETS_STORAGE, Key: "app/api", Value: []byte("second")},
+			})
+			if err != nil {
+				t.Fatalf("ApplyOperations: %v", err)
+			}
+			if _, err = be.Retrieve(SECRETS_STORAGE, "app/db"); !errors.Is(err, ErrKeyNotFound) {
+				t.Fatalf("deleted key: got %v, want %v", err, ErrKeyNotFound)
+			}
+			value, err := be.Retrieve(SECRETS_STORAGE, "app/api")
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			if string(value) != "second" {
+				t.Fatalf("got %q, want %q", value, "second")
+			}
+		})
+	}
+}
+
+func TestApplyOperationsUnknownType(t *testing.T) {
+	be := newTestFileStore(t, t.TempDir())
+	err := ApplyOperations(be, []Operation{{Type: OperationType(42), StorageId: SECRETS_STORAGE, Key: "app/db"}})
+	if err == nil {
+		t.Fatalf("ApplyOperations accepted an unknown operation type")
+	}
+	if _, err = be.Retrieve(SECRETS_STORAGE, "app/db"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Retrieve: got %v, want %v", err, ErrKeyNotFound)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -50,3 +51,49 @@ func GRPCRecoveryMiddleware(logger *zap.Logger) grpc.UnaryServerInterceptor {
 		return handler(ctx, req)
 	}
 }
+
+// StripMetadataMiddleware drops the given metadata keys from incoming gRPC
+// requests, so clients cannot set keys only trusted from in-process callers
+func GRPCStripMetadataMiddleware(keys ...string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		return handler(stripMetadata(ctx, keys), req)
+	}
+}
+
+// StripMetadataStreamMiddleware is StripMetadataMiddleware for streaming calls
+func GRPCStripMetadataStreamMiddleware(keys ...string) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &strippedStream{ServerStream: ss, ctx: stripMetadata(ss.Context(), keys)})
+	}
+}
+
+type strippedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *strippedStream) Context() context.Context {
+	return s.ctx
+}
+
+func stripMetadata(ctx context.Context, keys []string) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	md = md.Copy()
+	for _, key := range keys {
+		md.Delete(key)
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCStripMetadataMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want metadata.MD
+	}{
+		{"stripped key", metadata.Pairs("x-client", "1.2.3.4", "x-other", "kept"), metadata.Pairs("x-other", "kept")},
+		{"only other keys", metadata.Pairs("x-other", "kept"), metadata.Pairs("x-other", "kept")},
+		{"no metadata", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			before := tt.md.Len()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			var got metadata.MD
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got, _ = metadata.FromIncomingContext(ctx)
+				return nil, nil
+			}
+			interceptor := GRPCStripMetadataMiddleware("x-client")
+			if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler); err != nil {
+				t.Fatalf("interceptor: %v", err)
+			}
+			if len(got) != len(tt.want) || len(got.Get("x-client")) != 0 {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for key, values := range tt.want {
+				if g := got.Get(key); len(g) != len(values) || g[0] != values[0] {
+					t.Fatalf("%s: got %q, want %q", key, g, values)
+				}
+			}
+			// the caller's metadata is left alone
+			if tt.md.Len() != before {
+				t.Fatalf("the incoming metadata was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package operator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+)
+
+// runMaintenance turns maintenance mode on or off. Only a ready vault can
+// enter maintenance. While it is on, servers keep serving reads but refuse
+// every write to the keystore.
+func runMaintenance(args []string) error {
+	fs := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: keyhouse operator maintenance [flags] on|off|status")
+		fs.PrintDefaults()
+	}
+	var common commonFlags
+	common.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one of on, off or status")
+	}
+
+	ctx := context.Background()
+	sm, err := common.stateManager(ctx, common.logger())
+	if err != nil {
+		return err
+	}
+	switch fs.Arg(0) {
+	case "on":
+		err = sm.SetMaintenance(statemanager.WithActor(ctx, "operator"), true)
+	case "off":
+		err = sm.SetMaintenance(statemanager.WithActor(ctx, "operator"), false)
+	case "status":
+	default:
+		return fmt.Errorf("expected one of on, off or status, got %q", fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	enabled, err := sm.IsInMaintenance(ctx)
+	if err != nil {
+		return err
+	}
+	nodes, err := sm.ActiveNodes(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Maintenance mode: %t\n", enabled)
+	fmt.Printf("Running nodes: %d\n", len(nodes))
+	for _, node := range nodes {
+		fmt.Printf("  %s\n", node)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+
+	"go.uber.org/zap"
+)
+
+const (
+	DEFAULT_CHECKPOINT_PATH = "keyhouse-migrate.checkpoint"
+	// the checkpoint is saved after every CHECKPOINT_INTERVAL copied entries
+	CHECKPOINT_INTERVAL = 100
+)
+
+// checkpoint records how far a migration got so it can resume after the
+// last copied key. Keys are copied in the order keystore.Walk visits them.
+type checkpoint struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	StorageId   string `json:"storage_id"`
+	LastKey     string `json:"last_key"`
+	Copied      int    `json:"copied"`
+}
+
+type migrateFlags struct {
+	commonFlags
+	sourceType     string
+	sourceCfgPath  string
+	destType       string
+	destCfgPath    string
+	checkpointPath string
+}
+
+// runMigrate copies every entry of one keystore into another. Entries are
+// copied as they are stored, still encrypted by the barrier, so the vault
+// does not need to be unsealed.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: keyhouse operator migrate [flags]")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Every keyhouse server must be stopped or in maintenance mode.")
+		fs.PrintDefaults()
+	}
+	var f migrateFlags
+	f.register(fs)
+	fs.StringVar(&f.sourceType, "source-type", "", "source store type (postgres, redis, s3, etcd, file)")
+	fs.StringVar(&f.sourceCfgPath, "source-cfg-path", "", "source store configuration file path")
+	fs.StringVar(&f.destType, "destination-type", "", "destination store type (postgres, redis, s3, etcd, file)")
+	fs.StringVar(&f.destCfgPath, "destination-cfg-path", "", "destination store configuration file path")
+	fs.StringVar(&f.checkpointPath, "checkpoint", DEFAULT_CHECKPOINT_PATH, "file used to resume an interrupted migration")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.sourceType == "" || f.destType == "" {
+		fs.Usage()
+		return fmt.Errorf("source-type and destination-type are required")
+	}
+	if f.sourceType == "memory" || f.destType == "memory" {
+		return fmt.Errorf("cannot migrate to or from a memory store")
+	}
+	source := f.sourceType + ":" + f.sourceCfgPath
+	destination := f.destType + ":" + f.destCfgPath
+	if source == destination {
+		return fmt.Errorf("source and destination are the same store")
+	}
+
+	ctx := context.Background()
+	log := f.logger()
+	sm, err := f.stateManager(ctx, log)
+	if err != nil {
+		return err
+	}
+	nodes, err := sm.ActiveNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list running nodes: %w", err)
+	}
+	maintenance, err := sm.IsInMaintenance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check maintenance mode: %w", err)
+	}
+	if len(nodes) > 0 && !maintenance {
+		return fmt.Errorf("keyhouse is running on %s; stop it or enable maintenance mode first", strings.Join(nodes, ", "))
+	}
+
+	src, err := openStore(f.sourceType, f.sourceCfgPath, false)
+	if err != nil {
+		return fmt.Errorf("failed to open source store: %w", err)
+	}
+	defer src.Close()
+	dst, err := openStore(f.destType, f.destCfgPath, true)
+	if err != nil {
+		return fmt.Errorf("failed to open destination store: %w", err)
+	}
+	defer dst.Close()
+
+	cp, err := loadCheckpoint(f.checkpointPath)
+	if err != nil {
+		return err
+	}
+	if cp == nil {
+		if err = ensureEmpty(dst); err != nil {
+			return err
+		}
+		cp = &checkpoint{Source: source, Destination: destination, StorageId: keystore.StorageIDs[0]}
+		log.Info("starting migration", zap.String("source", source), zap.String("destination", destination))
+	} else {
+		if cp.Source != source || cp.Destination != destination {
+			return fmt.Errorf("checkpoint %s belongs to a migration from %s to %s", f.checkpointPath, cp.Source, cp.Destination)
+		}
+		log.Info("resuming migration", zap.String("storage_id", cp.StorageId), zap.String("after", cp.LastKey), zap.Int("copied", cp.Copied))
+	}
+
+	if err = copyEntries(log, src, dst, cp, f.checkpointPath); err != nil {
+		return err
+	}
+	log.Info("verifying migration", zap.Int("copied", cp.Copied))
+	if err = verifyStores(log, src, dst); err != nil {
+		return err
+	}
+	if err = os.Remove(f.checkpointPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Printf("Migrated %d entries from %s to %s\n", cp.Copied, source, destination)
+	return nil
+}
+
+func openStore(storeType, cfgPath string, migrate bool) (keystore.BackendKeyStore, error) {
+	store, err := keystore.NewKeystore(storeType, cfgPath)
+	if err != nil {
+		return nil, err
+	}
+	if err = store.Ping(); err != nil {
+		store.Close()
+		return nil, err
+	}
+	if migrator, ok := store.(keystore.Migrator); ok && migrate {
+		if err = migrator.Migrate(); err != nil {
+			store.Close()
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// ensureEmpty refuses to start a fresh migration into a store that already
+// holds data, which would otherwise be mixed with the copied entries.
+func ensureEmpty(store keystore.BackendKeyStore) error {
+	for _, storageId := range keystore.StorageIDs {
+		keys, _, err := store.List(storageId, "", "", 1)
+		if err != nil {
+			return fmt.Errorf("failed to list destination store: %w", err)
+		}
+		if len(keys) > 0 {
+			return fmt.Errorf("destination store is not empty: found %s/%s", storageId, keys[0])
+		}
+	}
+	return nil
+}
+
+func copyEntries(log *zap.Logger, src, dst keystore.BackendKeyStore, cp *checkpoint, cpPath string) error {
+	start := -1
+	for i, storageId := range keystore.StorageIDs {
+		if storageId == cp.StorageId {
+			start = i
+		}
+	}
+	if start < 0 {
+		return fmt.Errorf("checkpoint refers to unknown storage id %q", cp.StorageId)
+	}
+
+	for _, storageId := range keystore.StorageIDs[start:] {
+		if storageId != cp.StorageId {
+			cp.StorageId, cp.LastKey = storageId, ""
+		}
+		err := keystore.Walk(src, storageId, cp.LastKey, func(key string) error {
+			value, err := src.Retrieve(storageId, key)
+			if err != nil {
+				return fmt.Errorf("failed to read %s/%s: %w", storageId, key, err)
+			}
+			if err = dst.Store(storageId, key, value); err != nil {
+				return fmt.Errorf("failed to write %s/%s: %w", storageId, key, err)
+			}
+			cp.LastKey = key
+			cp.Copied++
+			if cp.Copied%CHECKPOINT_INTERVAL == 0 {
+				log.Info("migration progress", zap.String("storage_id", storageId), zap.Int("copied", cp.Copied))
+				return saveCheckpoint(cpPath, cp)
+			}
+			return nil
+		})
+		if err != nil {
+			// keep what was copied so far so the next run resumes from here
+			if cpErr := saveCheckpoint(cpPath, cp); cpErr != nil {
+				log.Error("failed to save checkpoint", zap.Error(cpErr))
+			}
+			return err
+		}
+		if err = saveCheckpoint(cpPath, cp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyStores compares the number of entries and a checksum of every key
+// and value of both stores.
+func verifyStores(log *zap.Logger, src, dst keystore.BackendKeyStore) error {
+	for _, storageId := range keystore.StorageIDs {
+		srcCount, srcSum, err := checksum(src, storageId)
+		if err != nil {
+			return fmt.Errorf("failed to checksum source %s: %w", storageId, err)
+		}
+		dstCount, dstSum, err := checksum(dst, storageId)
+		if err != nil {
+			return fmt.Errorf("failed to checksum destination %s: %w", storageId, err)
+		}
+		if srcCount != dstCount {
+			return fmt.Errorf("%s: source has %d entries but destination has %d", storageId, srcCount, dstCount)
+		}
+		if srcSum != dstSum {
+			return fmt.Errorf("%s: checksum mismatch between source and destination", storageId)
+		}
+		log.Info("verified storage", zap.String("storage_id", storageId), zap.Int("entries", srcCount), zap.String("sha256", srcSum))
+	}
+	return nil
+}
+
+func checksum(store keystore.BackendKeyStore, storageId string) (int, string, error) {
+	h := sha256.New()
+	count := 0
+	err := keystore.Walk(store, storageId, "", func(key string) error {
+		value, err := store.Retrieve(storageId, key)
+		if err != nil {
+			return err
+		}
+		writeField(h, []byte(key))
+		writeField(h, value)
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return count, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeField length-prefixes b so different key/value splits cannot collide.
+func writeField(h hash.Hash, b []byte) {
+	var size [8]byte
+	binary.BigEndian.PutUint64(size[:], uint64(len(b)))
+	h.Write(size[:])
+	h.Write(b)
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	var cp checkpoint
+	if err = json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint %s: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint replaces the checkpoint atomically so an interruption never
+// leaves a partial file behind.
+func saveCheckpoint(path string, cp *checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*")
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,137 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"go.uber.org/zap"
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// interruptingStore fails every Store after the first allowed ones, like a
+// migration that is killed halfway.
+type interruptingStore struct {
+	keystore.BackendKeyStore
+	allowed int
+}
+
+func (s *interruptingStore) Store(storageId, key string, value []byte) error {
+	if s.allowed == 0 {
+		return errInterrupted
+	}
+	s.allowed--
+	return s.BackendKeyStore.Store(storageId, key, value)
+}
+
+func newSourceStore(t *testing.T, entries int) keystore.BackendKeyStore {
+	t.Helper()
+	src := keystore.NewMemoryStore()
+	for _, storageId := range keystore.StorageIDs {
+		for i := 0; i < entries; i++ {
+			key := fmt.Sprintf("app/%03d", i)
+			if err := src.Store(storageId, key, []byte(storageId+"/"+key)); err != nil {
+				t.Fatalf("Store: %v", err)
+			}
+		}
+	}
+	return src
+}
+
+func TestCopyEntriesResume(t *testing.T) {
+	log := zap.NewNop()
+	src := newSourceStore(t, 5)
+	dst := keystore.NewMemoryStore()
+	cpPath := filepath.Join(t.TempDir(), "checkpoint")
+	cp := &checkpoint{Source: "src", Destination: "dst", StorageId: keystore.StorageIDs[0]}
+
+	// stop in the middle of the second storage id
+	err := copyEntries(log, src, &interruptingStore{BackendKeyStore: dst, allowed: 7}, cp, cpPath)
+	if !errors.Is(err, errInterrupted) {
+		t.Fatalf("copyEntries: got %v, want %v", err, errInterrupted)
+	}
+	saved, err := loadCheckpoint(cpPath)
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	want := &checkpoint{Source: "src", Destination: "dst", StorageId: keystore.StorageIDs[1], LastKey: "app/001", Copied: 7}
+	if !reflect.DeepEqual(saved, want) {
+		t.Fatalf("checkpoint: got %+v, want %+v", saved, want)
+	}
+	if err = verifyStores(log, src, dst); err == nil {
+		t.Fatalf("verifyStores passed on a partial copy")
+	}
+
+	if err = copyEntries(log, src, dst, saved, cpPath); err != nil {
+		t.Fatalf("resumed copyEntries: %v", err)
+	}
+	if saved.Copied != 5*len(keystore.StorageIDs) {
+		t.Fatalf("copied %d entries, want %d", saved.Copied, 5*len(keystore.StorageIDs))
+	}
+	if err = verifyStores(log, src, dst); err != nil {
+		t.Fatalf("verifyStores: %v", err)
+	}
+}
+
+func TestVerifyStores(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(dst keystore.BackendKeyStore) error
+		ok     bool
+	}{
+		{"identical", func(dst keystore.BackendKeyStore) error { return nil }, true},
+		{"missing entry", func(dst keystore.BackendKeyStore) error {
+			return dst.Delete(keystore.SECRETS_STORAGE, "app/001")
+		}, false},
+		{"extra entry", func(dst keystore.BackendKeyStore) error {
+			return dst.Store(keystore.SECRETS_STORAGE, "app/999", []byte("x"))
+		}, false},
+		{"changed value", func(dst keystore.BackendKeyStore) error {
+			return dst.Store(keystore.SECRETS_STORAGE, "app/001", []byte("x"))
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newSourceStore(t, 3)
+			dst := newSourceStore(t, 3)
+			if err := tt.change(dst); err != nil {
+				t.Fatalf("change: %v", err)
+			}
+			err := verifyStores(zap.NewNop(), src, dst)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEnsureEmpty(t *testing.T) {
+	if err := ensureEmpty(keystore.NewMemoryStore()); err != nil {
+		t.Fatalf("empty store: %v", err)
+	}
+	if err := ensureEmpty(newSourceStore(t, 1)); err == nil {
+		t.Fatalf("ensureEmpty passed on a store with data")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := loadCheckpoint(path)
+	if err != nil || cp != nil {
+		t.Fatalf("missing checkpoint: got %+v, %v", cp, err)
+	}
+	want := &checkpoint{Source: "file:a", Destination: "file:b", StorageId: keystore.SECRETS_STORAGE, LastKey: "app/db", Copied: 42}
+	if err = saveCheckpoint(path, want); err != nil {
+		t.Fatalf("saveCheckpoint: %v", err)
+	}
+	if cp, err = loadCheckpoint(path); err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	if !reflect.DeepEqual(cp, want) {
+		t.Fatalf("got %+v, want %+v", cp, want)
+	}
+}
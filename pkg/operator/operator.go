@@ -0,0 +1,108 @@
+package operator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/logger"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+
+	"go.uber.org/zap"
+)
+
+const usage = `Usage: keyhouse operator <command> [flags]
+
+Commands:
+  migrate           copy every keystore entry to another backend
+  maintenance       turn maintenance mode on or off
+  snapshot          save or restore a snapshot of a running server
+  rotate-init-code  replace the init code of an uninitialized vault
+`
+
+// Run executes an operator command and returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "migrate":
+		err = runMigrate(args[1:])
+	case "maintenance":
+		err = runMaintenance(args[1:])
+	case "snapshot":
+		err = runSnapshot(args[1:])
+	case "rotate-init-code":
+		err = runRotateInitCode(args[1:])
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown operator command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	if err == flag.ErrHelp {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// commonFlags holds the flags every operator command needs to reach the
+// state database.
+type commonFlags struct {
+	logLevel      string
+	stateType     string
+	stateCfgPath  string
+	redisHost     string
+	redisPort     string
+	redisPassword string
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.logLevel, "log-level", "info", "log level (debug, info, warn, error)")
+	fs.StringVar(&c.stateType, "state-type", "redis", "state database type (redis, postgres)")
+	fs.StringVar(&c.stateCfgPath, "state-cfg-path", "", "postgres configuration file path of the state database")
+	fs.StringVar(&c.redisHost, "redis-host", "localhost", "Redis host")
+	fs.StringVar(&c.redisPort, "redis-port", "6379", "Redis port")
+	fs.StringVar(&c.redisPassword, "redis-password", "admin", "Redis password")
+}
+
+func (c *commonFlags) logger() *zap.Logger {
+	return logger.NewLogger(c.logLevel).With(zap.String("component", "operator"))
+}
+
+func (c *commonFlags) stateManager(ctx context.Context, log *zap.Logger) (*statemanager.StateManager, error) {
+	var sm *statemanager.StateManager
+	switch c.stateType {
+	case "redis":
+		sm = statemanager.NewStateManager(log, c.redisHost, c.redisPort, c.redisPassword)
+	case "postgres":
+		pgCfg, err := keystore.LoadPostgresConfig(c.stateCfgPath)
+		if err != nil {
+			return nil, err
+		}
+		pg, err := keystore.NewPostgresStore(pgCfg)
+		if err != nil {
+			return nil, err
+		}
+		// the state tables are created by the keystore migrations
+		if err = pg.Migrate(); err != nil {
+			return nil, fmt.Errorf("failed to migrate state db: %w", err)
+		}
+		sm = statemanager.NewStateManagerWithDB(log, statemanager.NewPostgresDB(log, pg.DB(), pg.ConnString()))
+	default:
+		return nil, fmt.Errorf("unknown state type %s", c.stateType)
+	}
+	if err := sm.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to state db: %w", err)
+	}
+	return sm, nil
+}
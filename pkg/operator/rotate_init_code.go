@@ -0,0 +1,59 @@
+package operator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+)
+
+// runRotateInitCode replaces the init code of an uninitialized vault, for
+// when the old one expired, leaked, or was used up by a restore.
+func runRotateInitCode(args []string) error {
+	fs := flag.NewFlagSet("rotate-init-code", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: keyhouse operator rotate-init-code [flags]")
+		fs.PrintDefaults()
+	}
+	var common commonFlags
+	common.register(fs)
+	var ttl time.Duration
+	fs.DurationVar(&ttl, "init-code-ttl", 24*time.Hour, "how long the new init code stays valid (0 never expires)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if ttl < 0 {
+		return fmt.Errorf("init-code-ttl cannot be negative")
+	}
+
+	ctx := context.Background()
+	sm, err := common.stateManager(ctx, common.logger())
+	if err != nil {
+		return err
+	}
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read vault state: %w", err)
+	}
+	unverified, err := sm.SealConfigUnverified(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read seal config: %w", err)
+	}
+	// a restored snapshot that no unseal has verified can be restored over
+	// with the init code
+	if state != statemanager.VAULT_STATE_DOWN && !unverified {
+		return fmt.Errorf("vault is already initialized")
+	}
+	sm.SetInitCodeTTL(ttl)
+	code, err := sm.RotateInitCode(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Init Code: %s\n", code)
+	if ttl > 0 {
+		fmt.Printf("Expires: %s\n", time.Now().Add(ttl).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package operator
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/snapshot"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	DEFAULT_ADDRESS   = "localhost:9090"
+	TOKEN_ENV         = "KEYHOUSE_TOKEN"
+	ROOT_TOKEN_HEADER = "x-keyhouse-token"
+	INIT_CODE_HEADER  = "x-keyhouse-init-code"
+	// chunks sent to the server while restoring a snapshot
+	RESTORE_CHUNK_SIZE = 64 << 10
+)
+
+// clientFlags holds the flags of commands that talk to a running server.
+type clientFlags struct {
+	address  string
+	token    string
+	initCode string
+}
+
+func (c *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.address, "address", DEFAULT_ADDRESS, "gRPC address of the keyhouse server")
+	fs.StringVar(&c.token, "token", os.Getenv(TOKEN_ENV), "root token, defaults to $"+TOKEN_ENV)
+}
+
+func (c *clientFlags) dial() (app.AppClient, *grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(c.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s: %w", c.address, err)
+	}
+	return app.NewAppClient(conn), conn, nil
+}
+
+func (c *clientFlags) context() context.Context {
+	ctx := context.Background()
+	if c.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, ROOT_TOKEN_HEADER, c.token)
+	}
+	if c.initCode != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, INIT_CODE_HEADER, c.initCode)
+	}
+	return ctx
+}
+
+// runSnapshot saves or restores a snapshot of a running server.
+func runSnapshot(args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: keyhouse operator snapshot [flags] save|restore FILE")
+		fs.PrintDefaults()
+	}
+	var client clientFlags
+	client.register(fs)
+	fs.StringVar(&client.initCode, "init-code", "", "init code authorizing a restore into a vault that is not initialized")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("expected save or restore and a file")
+	}
+	switch fs.Arg(0) {
+	case "save":
+		return saveSnapshot(&client, fs.Arg(1))
+	case "restore":
+		return restoreSnapshot(&client, fs.Arg(1))
+	default:
+		return fmt.Errorf("expected save or restore, got %q", fs.Arg(0))
+	}
+}
+
+// saveSnapshot downloads a snapshot into a temporary file and only moves it
+// into place once its checksum has been verified.
+func saveSnapshot(client *clientFlags, path string) error {
+	c, conn, err := client.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := c.SaveSnapshot(client.context(), &app.SaveSnapshotRequest{})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if _, err = tmp.Write(chunk.GetData()); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	header, count, err := snapshot.Verify(tmp)
+	if err != nil {
+		return fmt.Errorf("downloaded snapshot is invalid: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	fmt.Printf("Saved snapshot of %d entries taken at %s to %s\n", count, header.CreatedAt.Format("2006-01-02T15:04:05Z07:00"), path)
+	return nil
+}
+
+// restoreSnapshot checks the snapshot locally before sending it, so a
+// corrupt file is caught before the server seals the vault.
+func restoreSnapshot(client *clientFlags, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, _, err = snapshot.Verify(f); err != nil {
+		return fmt.Errorf("snapshot %s is invalid: %w", path, err)
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	c, conn, err := client.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stream, err := c.RestoreSnapshot(client.context())
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, RESTORE_CHUNK_SIZE)
+	for {
+		n, err := f.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&app.SnapshotChunk{Data: buf[:n]}); sendErr != nil {
+				// the server closed the stream, its status is returned below
+				if sendErr == io.EOF {
+					break
+				}
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d entries. Vault is %s: %s\n", resp.GetEntries(), resp.GetStatus(), resp.GetMessage())
+	return nil
+}
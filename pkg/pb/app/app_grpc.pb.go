@@ -19,9 +19,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	App_GetStatus_FullMethodName    = "/com.skriptvalley.keyhouse.App/GetStatus"
-	App_InitKeyhouse_FullMethodName = "/com.skriptvalley.keyhouse.App/InitKeyhouse"
-	App_ActivateKey_FullMethodName  = "/com.skriptvalley.keyhouse.App/ActivateKey"
+	App_GetStatus_FullMethodName            = "/com.skriptvalley.keyhouse.App/GetStatus"
+	App_InitKeyhouse_FullMethodName         = "/com.skriptvalley.keyhouse.App/InitKeyhouse"
+	App_ActivateKey_FullMethodName          = "/com.skriptvalley.keyhouse.App/ActivateKey"
+	App_ResetUnseal_FullMethodName          = "/com.skriptvalley.keyhouse.App/ResetUnseal"
+	App_GetStateHistory_FullMethodName      = "/com.skriptvalley.keyhouse.App/GetStateHistory"
+	App_PutSecret_FullMethodName            = "/com.skriptvalley.keyhouse.App/PutSecret"
+	App_GetSecret_FullMethodName            = "/com.skriptvalley.keyhouse.App/GetSecret"
+	App_DeleteSecret_FullMethodName         = "/com.skriptvalley.keyhouse.App/DeleteSecret"
+	App_UndeleteSecret_FullMethodName       = "/com.skriptvalley.keyhouse.App/UndeleteSecret"
+	App_DestroySecret_FullMethodName        = "/com.skriptvalley.keyhouse.App/DestroySecret"
+	App_GetSecretMetadata_FullMethodName    = "/com.skriptvalley.keyhouse.App/GetSecretMetadata"
+	App_UpdateSecretMetadata_FullMethodName = "/com.skriptvalley.keyhouse.App/UpdateSecretMetadata"
+	App_ListSecrets_FullMethodName          = "/com.skriptvalley.keyhouse.App/ListSecrets"
+	App_Seal_FullMethodName                 = "/com.skriptvalley.keyhouse.App/Seal"
+	App_SaveSnapshot_FullMethodName         = "/com.skriptvalley.keyhouse.App/SaveSnapshot"
+	App_RestoreSnapshot_FullMethodName      = "/com.skriptvalley.keyhouse.App/RestoreSnapshot"
 )
 
 // AppClient is the client API for App service.
@@ -39,6 +52,50 @@ type AppClient interface {
 	// ActivateKey RPC
 	// Returns status of app after activating given keyholder
 	ActivateKey(ctx context.Context, in *ActivateKeyRequest, opts ...grpc.CallOption) (*ActivateKeyResponse, error)
+	// ResetUnseal RPC
+	// Discards every unseal key share submitted so far
+	ResetUnseal(ctx context.Context, in *ResetUnsealRequest, opts ...grpc.CallOption) (*ResetUnsealResponse, error)
+	// GetStateHistory RPC
+	// Returns the latest vault state transitions. Requires the root token
+	// unless the vault is not initialized
+	GetStateHistory(ctx context.Context, in *StateHistoryRequest, opts ...grpc.CallOption) (*StateHistoryResponse, error)
+	// PutSecret RPC
+	// Writes a new version of the secret stored at the given path
+	PutSecret(ctx context.Context, in *PutSecretRequest, opts ...grpc.CallOption) (*PutSecretResponse, error)
+	// GetSecret RPC
+	// Returns a version of the secret stored at the given path
+	GetSecret(ctx context.Context, in *GetSecretRequest, opts ...grpc.CallOption) (*GetSecretResponse, error)
+	// DeleteSecret RPC
+	// Soft-deletes versions of the secret stored at the given path
+	DeleteSecret(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*DeleteSecretResponse, error)
+	// UndeleteSecret RPC
+	// Restores soft-deleted versions of a secret
+	UndeleteSecret(ctx context.Context, in *UndeleteSecretRequest, opts ...grpc.CallOption) (*UndeleteSecretResponse, error)
+	// DestroySecret RPC
+	// Permanently removes the data of versions of a secret
+	DestroySecret(ctx context.Context, in *DestroySecretRequest, opts ...grpc.CallOption) (*DestroySecretResponse, error)
+	// GetSecretMetadata RPC
+	// Returns the metadata and version history of a secret
+	GetSecretMetadata(ctx context.Context, in *GetSecretMetadataRequest, opts ...grpc.CallOption) (*SecretMetadata, error)
+	// UpdateSecretMetadata RPC
+	// Changes the settings of a secret
+	UpdateSecretMetadata(ctx context.Context, in *UpdateSecretMetadataRequest, opts ...grpc.CallOption) (*SecretMetadata, error)
+	// ListSecrets RPC
+	// Returns a page of the secrets and sub-folders in a folder
+	ListSecrets(ctx context.Context, in *ListSecretsRequest, opts ...grpc.CallOption) (*ListSecretsResponse, error)
+	// Seal RPC
+	// Drops the master key from memory and locks the vault until keyholders
+	// unlock it again. Requires the root token
+	Seal(ctx context.Context, in *SealRequest, opts ...grpc.CallOption) (*SealResponse, error)
+	// SaveSnapshot RPC
+	// Streams an archive of the keystore and seal configuration. Requires the
+	// root token. Only available over gRPC
+	SaveSnapshot(ctx context.Context, in *SaveSnapshotRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotChunk], error)
+	// RestoreSnapshot RPC
+	// Replaces the keystore and seal configuration with an archive and seals
+	// the vault. Requires the root token unless the vault is not initialized.
+	// Only available over gRPC
+	RestoreSnapshot(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotChunk, RestoreSnapshotResponse], error)
 }
 
 type appClient struct {
@@ -79,6 +136,148 @@ func (c *appClient) ActivateKey(ctx context.Context, in *ActivateKeyRequest, opt
 	return out, nil
 }
 
+func (c *appClient) ResetUnseal(ctx context.Context, in *ResetUnsealRequest, opts ...grpc.CallOption) (*ResetUnsealResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetUnsealResponse)
+	err := c.cc.Invoke(ctx, App_ResetUnseal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) GetStateHistory(ctx context.Context, in *StateHistoryRequest, opts ...grpc.CallOption) (*StateHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateHistoryResponse)
+	err := c.cc.Invoke(ctx, App_GetStateHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) PutSecret(ctx context.Context, in *PutSecretRequest, opts ...grpc.CallOption) (*PutSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutSecretResponse)
+	err := c.cc.Invoke(ctx, App_PutSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) GetSecret(ctx context.Context, in *GetSecretRequest, opts ...grpc.CallOption) (*GetSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSecretResponse)
+	err := c.cc.Invoke(ctx, App_GetSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) DeleteSecret(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*DeleteSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSecretResponse)
+	err := c.cc.Invoke(ctx, App_DeleteSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) UndeleteSecret(ctx context.Context, in *UndeleteSecretRequest, opts ...grpc.CallOption) (*UndeleteSecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndeleteSecretResponse)
+	err := c.cc.Invoke(ctx, App_UndeleteSecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) DestroySecret(ctx context.Context, in *DestroySecretRequest, opts ...grpc.CallOption) (*DestroySecretResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DestroySecretResponse)
+	err := c.cc.Invoke(ctx, App_DestroySecret_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) GetSecretMetadata(ctx context.Context, in *GetSecretMetadataRequest, opts ...grpc.CallOption) (*SecretMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SecretMetadata)
+	err := c.cc.Invoke(ctx, App_GetSecretMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) UpdateSecretMetadata(ctx context.Context, in *UpdateSecretMetadataRequest, opts ...grpc.CallOption) (*SecretMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SecretMetadata)
+	err := c.cc.Invoke(ctx, App_UpdateSecretMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) ListSecrets(ctx context.Context, in *ListSecretsRequest, opts ...grpc.CallOption) (*ListSecretsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSecretsResponse)
+	err := c.cc.Invoke(ctx, App_ListSecrets_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) Seal(ctx context.Context, in *SealRequest, opts ...grpc.CallOption) (*SealResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SealResponse)
+	err := c.cc.Invoke(ctx, App_Seal_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appClient) SaveSnapshot(ctx context.Context, in *SaveSnapshotRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SnapshotChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &App_ServiceDesc.Streams[0], App_SaveSnapshot_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SaveSnapshotRequest, SnapshotChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type App_SaveSnapshotClient = grpc.ServerStreamingClient[SnapshotChunk]
+
+func (c *appClient) RestoreSnapshot(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SnapshotChunk, RestoreSnapshotResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &App_ServiceDesc.Streams[1], App_RestoreSnapshot_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotChunk, RestoreSnapshotResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type App_RestoreSnapshotClient = grpc.ClientStreamingClient[SnapshotChunk, RestoreSnapshotResponse]
+
 // AppServer is the server API for App service.
 // All implementations must embed UnimplementedAppServer
 // for forward compatibility.
@@ -94,6 +293,50 @@ type AppServer interface {
 	// ActivateKey RPC
 	// Returns status of app after activating given keyholder
 	ActivateKey(context.Context, *ActivateKeyRequest) (*ActivateKeyResponse, error)
+	// ResetUnseal RPC
+	// Discards every unseal key share submitted so far
+	ResetUnseal(context.Context, *ResetUnsealRequest) (*ResetUnsealResponse, error)
+	// GetStateHistory RPC
+	// Returns the latest vault state transitions. Requires the root token
+	// unless the vault is not initialized
+	GetStateHistory(context.Context, *StateHistoryRequest) (*StateHistoryResponse, error)
+	// PutSecret RPC
+	// Writes a new version of the secret stored at the given path
+	PutSecret(context.Context, *PutSecretRequest) (*PutSecretResponse, error)
+	// GetSecret RPC
+	// Returns a version of the secret stored at the given path
+	GetSecret(context.Context, *GetSecretRequest) (*GetSecretResponse, error)
+	// DeleteSecret RPC
+	// Soft-deletes versions of the secret stored at the given path
+	DeleteSecret(context.Context, *DeleteSecretRequest) (*DeleteSecretResponse, error)
+	// UndeleteSecret RPC
+	// Restores soft-deleted versions of a secret
+	UndeleteSecret(context.Context, *UndeleteSecretRequest) (*UndeleteSecretResponse, error)
+	// DestroySecret RPC
+	// Permanently removes the data of versions of a secret
+	DestroySecret(context.Context, *DestroySecretRequest) (*DestroySecretResponse, error)
+	// GetSecretMetadata RPC
+	// Returns the metadata and version history of a secret
+	GetSecretMetadata(context.Context, *GetSecretMetadataRequest) (*SecretMetadata, error)
+	// UpdateSecretMetadata RPC
+	// Changes the settings of a secret
+	UpdateSecretMetadata(context.Context, *UpdateSecretMetadataRequest) (*SecretMetadata, error)
+	// ListSecrets RPC
+	// Returns a page of the secrets and sub-folders in a folder
+	ListSecrets(context.Context, *ListSecretsRequest) (*ListSecretsResponse, error)
+	// Seal RPC
+	// Drops the master key from memory and locks the vault until keyholders
+	// unlock it again. Requires the root token
+	Seal(context.Context, *SealRequest) (*SealResponse, error)
+	// SaveSnapshot RPC
+	// Streams an archive of the keystore and seal configuration. Requires the
+	// root token. Only available over gRPC
+	SaveSnapshot(*SaveSnapshotRequest, grpc.ServerStreamingServer[SnapshotChunk]) error
+	// RestoreSnapshot RPC
+	// Replaces the keystore and seal configuration with an archive and seals
+	// the vault. Requires the root token unless the vault is not initialized.
+	// Only available over gRPC
+	RestoreSnapshot(grpc.ClientStreamingServer[SnapshotChunk, RestoreSnapshotResponse]) error
 	mustEmbedUnimplementedAppServer()
 }
 
@@ -113,6 +356,45 @@ func (UnimplementedAppServer) InitKeyhouse(context.Context, *InitRequest) (*Init
 func (UnimplementedAppServer) ActivateKey(context.Context, *ActivateKeyRequest) (*ActivateKeyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ActivateKey not implemented")
 }
+func (UnimplementedAppServer) ResetUnseal(context.Context, *ResetUnsealRequest) (*ResetUnsealResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetUnseal not implemented")
+}
+func (UnimplementedAppServer) GetStateHistory(context.Context, *StateHistoryRequest) (*StateHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStateHistory not implemented")
+}
+func (UnimplementedAppServer) PutSecret(context.Context, *PutSecretRequest) (*PutSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutSecret not implemented")
+}
+func (UnimplementedAppServer) GetSecret(context.Context, *GetSecretRequest) (*GetSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSecret not implemented")
+}
+func (UnimplementedAppServer) DeleteSecret(context.Context, *DeleteSecretRequest) (*DeleteSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSecret not implemented")
+}
+func (UnimplementedAppServer) UndeleteSecret(context.Context, *UndeleteSecretRequest) (*UndeleteSecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteSecret not implemented")
+}
+func (UnimplementedAppServer) DestroySecret(context.Context, *DestroySecretRequest) (*DestroySecretResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DestroySecret not implemented")
+}
+func (UnimplementedAppServer) GetSecretMetadata(context.Context, *GetSecretMetadataRequest) (*SecretMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSecretMetadata not implemented")
+}
+func (UnimplementedAppServer) UpdateSecretMetadata(context.Context, *UpdateSecretMetadataRequest) (*SecretMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSecretMetadata not implemented")
+}
+func (UnimplementedAppServer) ListSecrets(context.Context, *ListSecretsRequest) (*ListSecretsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSecrets not implemented")
+}
+func (UnimplementedAppServer) Seal(context.Context, *SealRequest) (*SealResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Seal not implemented")
+}
+func (UnimplementedAppServer) SaveSnapshot(*SaveSnapshotRequest, grpc.ServerStreamingServer[SnapshotChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method SaveSnapshot not implemented")
+}
+func (UnimplementedAppServer) RestoreSnapshot(grpc.ClientStreamingServer[SnapshotChunk, RestoreSnapshotResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method RestoreSnapshot not implemented")
+}
 func (UnimplementedAppServer) mustEmbedUnimplementedAppServer() {}
 func (UnimplementedAppServer) testEmbeddedByValue()             {}
 
@@ -188,6 +470,222 @@ func _App_ActivateKey_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _App_ResetUnseal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetUnsealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).ResetUnseal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_ResetUnseal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).ResetUnseal(ctx, req.(*ResetUnsealRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_GetStateHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).GetStateHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_GetStateHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).GetStateHistory(ctx, req.(*StateHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_PutSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).PutSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_PutSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).PutSecret(ctx, req.(*PutSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_GetSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).GetSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_GetSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).GetSecret(ctx, req.(*GetSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_DeleteSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).DeleteSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_DeleteSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).DeleteSecret(ctx, req.(*DeleteSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_UndeleteSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteSecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).UndeleteSecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_UndeleteSecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).UndeleteSecret(ctx, req.(*UndeleteSecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_DestroySecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DestroySecretRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).DestroySecret(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_DestroySecret_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).DestroySecret(ctx, req.(*DestroySecretRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_GetSecretMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSecretMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).GetSecretMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_GetSecretMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).GetSecretMetadata(ctx, req.(*GetSecretMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_UpdateSecretMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSecretMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).UpdateSecretMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_UpdateSecretMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).UpdateSecretMetadata(ctx, req.(*UpdateSecretMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_ListSecrets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSecretsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).ListSecrets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_ListSecrets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).ListSecrets(ctx, req.(*ListSecretsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_Seal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppServer).Seal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: App_Seal_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppServer).Seal(ctx, req.(*SealRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _App_SaveSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SaveSnapshotRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AppServer).SaveSnapshot(m, &grpc.GenericServerStream[SaveSnapshotRequest, SnapshotChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type App_SaveSnapshotServer = grpc.ServerStreamingServer[SnapshotChunk]
+
+func _App_RestoreSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AppServer).RestoreSnapshot(&grpc.GenericServerStream[SnapshotChunk, RestoreSnapshotResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type App_RestoreSnapshotServer = grpc.ClientStreamingServer[SnapshotChunk, RestoreSnapshotResponse]
+
 // App_ServiceDesc is the grpc.ServiceDesc for App service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -207,7 +705,62 @@ var App_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ActivateKey",
 			Handler:    _App_ActivateKey_Handler,
 		},
+		{
+			MethodName: "ResetUnseal",
+			Handler:    _App_ResetUnseal_Handler,
+		},
+		{
+			MethodName: "GetStateHistory",
+			Handler:    _App_GetStateHistory_Handler,
+		},
+		{
+			MethodName: "PutSecret",
+			Handler:    _App_PutSecret_Handler,
+		},
+		{
+			MethodName: "GetSecret",
+			Handler:    _App_GetSecret_Handler,
+		},
+		{
+			MethodName: "DeleteSecret",
+			Handler:    _App_DeleteSecret_Handler,
+		},
+		{
+			MethodName: "UndeleteSecret",
+			Handler:    _App_UndeleteSecret_Handler,
+		},
+		{
+			MethodName: "DestroySecret",
+			Handler:    _App_DestroySecret_Handler,
+		},
+		{
+			MethodName: "GetSecretMetadata",
+			Handler:    _App_GetSecretMetadata_Handler,
+		},
+		{
+			MethodName: "UpdateSecretMetadata",
+			Handler:    _App_UpdateSecretMetadata_Handler,
+		},
+		{
+			MethodName: "ListSecrets",
+			Handler:    _App_ListSecrets_Handler,
+		},
+		{
+			MethodName: "Seal",
+			Handler:    _App_Seal_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SaveSnapshot",
+			Handler:       _App_SaveSnapshot_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreSnapshot",
+			Handler:       _App_RestoreSnapshot_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "app.proto",
 }
@@ -0,0 +1,458 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+)
+
+const (
+	DEFAULT_MAX_VERSIONS = 10
+)
+
+var (
+	ErrSecretNotFound  = errors.New("secret not found")
+	ErrVersionNotFound = errors.New("secret version not found")
+	ErrVersionDeleted  = errors.New("secret version is deleted")
+	ErrInvalidPath     = errors.New("invalid secret path")
+	ErrNoVersions      = errors.New("at least one version is required")
+	ErrCASMismatch     = errors.New("check-and-set version does not match the current version")
+	ErrCASRequired     = errors.New("check-and-set version is required for this secret")
+)
+
+// VersionMetadata describes a single version of a secret.
+type VersionMetadata struct {
+	CreatedTime  time.Time  `json:"created_time"`
+	DeletionTime *time.Time `json:"deletion_time,omitempty"`
+	Destroyed    bool       `json:"destroyed"`
+}
+
+// Metadata is stored once per secret path and tracks all of its versions.
+type Metadata struct {
+	CreatedTime    time.Time                  `json:"created_time"`
+	UpdatedTime    time.Time                  `json:"updated_time"`
+	CurrentVersion int64                      `json:"current_version"`
+	OldestVersion  int64                      `json:"oldest_version"`
+	MaxVersions    int                        `json:"max_versions"`
+	CASRequired    bool                       `json:"cas_required"`
+	Versions       map[int64]*VersionMetadata `json:"versions"`
+}
+
+// MetadataUpdate holds the settings to change on a secret; nil fields are
+// left untouched.
+type MetadataUpdate struct {
+	MaxVersions *int
+	CASRequired *bool
+}
+
+// Secret is a single version of a secret.
+type Secret struct {
+	Data     map[string]string
+	Version  int64
+	Metadata *VersionMetadata
+}
+
+// PathLocker serializes writes to a secret path between replicas sharing
+// the backend. LockPath returns the function releasing the lock.
+type PathLocker interface {
+	LockPath(path string) (func(), error)
+}
+
+// Engine stores versioned key/value secrets in a BackendKeyStore.
+type Engine struct {
+	be          keystore.BackendKeyStore
+	maxVersions int
+	locker      PathLocker
+	// evicts a cached entry, so metadata is read fresh under the path lock
+	invalidate func(storageId, key string)
+	// serializes read-modify-write cycles of metadata and the path index
+	mu sync.Mutex
+}
+
+// NewEngine returns an engine that keeps maxVersions versions of each
+// secret unless the secret's metadata overrides it.
+func NewEngine(be keystore.BackendKeyStore, maxVersions int) *Engine {
+	if maxVersions <= 0 {
+		maxVersions = DEFAULT_MAX_VERSIONS
+	}
+	return &Engine{be: be, maxVersions: maxVersions}
+}
+
+// SetPathLocker makes every metadata update hold the path lock from locker
+// around its read-modify-write. Without one, writes are only serialized
+// within this process. invalidate, if set, evicts the cached metadata once
+// the lock is held, since another replica may just have rewritten it.
+func (e *Engine) SetPathLocker(locker PathLocker, invalidate func(storageId, key string)) {
+	e.locker = locker
+	e.invalidate = invalidate
+}
+
+// lockPath takes the lock on path and the engine mutex, and returns the
+// function releasing both.
+func (e *Engine) lockPath(path string) (func(), error) {
+	unlock := func() {}
+	if e.locker != nil {
+		var err error
+		if unlock, err = e.locker.LockPath(path); err != nil {
+			return nil, err
+		}
+		if e.invalidate != nil {
+			e.invalidate(keystore.SECRETS_STORAGE, path)
+		}
+	}
+	e.mu.Lock()
+	return func() {
+		e.mu.Unlock()
+		unlock()
+	}, nil
+}
+
+// NormalizePath trims surrounding slashes and rejects empty paths.
+func NormalizePath(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", ErrInvalidPath
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidPath
+		}
+	}
+	return path, nil
+}
+
+// Put writes a new version of the secret and returns its number. When cas
+// is set, the write only succeeds if it matches the current version, with 0
+// meaning the secret must not exist yet.
+func (e *Engine) Put(path string, data map[string]string, cas *int64) (int64, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return 0, err
+	}
+	value, err := json.Marshal(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	unlock, err := e.lockPath(path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	now := time.Now().UTC()
+	meta, err := e.readMetadata(path)
+	if errors.Is(err, ErrSecretNotFound) {
+		meta = newMetadata(now)
+	} else if err != nil {
+		return 0, err
+	}
+	if cas == nil && meta.CASRequired {
+		return 0, ErrCASRequired
+	}
+	if cas != nil && *cas != meta.CurrentVersion {
+		return 0, ErrCASMismatch
+	}
+
+	version := meta.CurrentVersion + 1
+	meta.Versions[version] = &VersionMetadata{CreatedTime: now}
+	meta.CurrentVersion = version
+	meta.UpdatedTime = now
+	pruned := e.prune(path, meta)
+	metaOp, err := metadataOperation(path, meta)
+	if err != nil {
+		return 0, err
+	}
+	ops := []keystore.Operation{{
+		Type:      keystore.PUT_OPERATION,
+		StorageId: keystore.SECRET_VERSIONS_STORAGE,
+		Key:       versionKey(path, version),
+		Value:     value,
+	}, metaOp}
+	if err = keystore.ApplyOperations(e.be, append(ops, pruned...)); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Get returns the given version of a secret, or the current version when
+// version is 0.
+func (e *Engine) Get(path string, version int64) (*Secret, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := e.readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = meta.CurrentVersion
+	}
+	vm, ok := meta.Versions[version]
+	if !ok {
+		return nil, ErrVersionNotFound
+	}
+	if vm.Destroyed || vm.DeletionTime != nil {
+		return nil, ErrVersionDeleted
+	}
+	value, err := e.be.Retrieve(keystore.SECRET_VERSIONS_STORAGE, versionKey(path, version))
+	if errors.Is(err, keystore.ErrKeyNotFound) {
+		return nil, ErrVersionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	data := make(map[string]string)
+	if err = json.Unmarshal(value, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+	return &Secret{Data: data, Version: version, Metadata: vm}, nil
+}
+
+// Delete soft-deletes the given versions, or the current version when none
+// are given. Deleted versions can be restored with Undelete.
+func (e *Engine) Delete(path string, versions []int64) ([]int64, error) {
+	return e.updateVersions(path, versions, true, func(vm *VersionMetadata, now time.Time) {
+		if vm.DeletionTime == nil {
+			vm.DeletionTime = &now
+		}
+	})
+}
+
+// Undelete restores soft-deleted versions that have not been destroyed.
+func (e *Engine) Undelete(path string, versions []int64) ([]int64, error) {
+	return e.updateVersions(path, versions, false, func(vm *VersionMetadata, _ time.Time) {
+		vm.DeletionTime = nil
+	})
+}
+
+// Destroy permanently removes the data of the given versions.
+func (e *Engine) Destroy(path string, versions []int64) ([]int64, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrNoVersions
+	}
+
+	unlock, err := e.lockPath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	meta, err := e.readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	var destroyed []int64
+	var deletes []keystore.Operation
+	for _, version := range versions {
+		vm, ok := meta.Versions[version]
+		if !ok || vm.Destroyed {
+			continue
+		}
+		vm.Destroyed = true
+		destroyed = append(destroyed, version)
+		deletes = append(deletes, keystore.Operation{
+			Type:      keystore.DELETE_OPERATION,
+			StorageId: keystore.SECRET_VERSIONS_STORAGE,
+			Key:       versionKey(path, version),
+		})
+	}
+	meta.UpdatedTime = time.Now().UTC()
+	metaOp, err := metadataOperation(path, meta)
+	if err != nil {
+		return nil, err
+	}
+	// metadata goes first so a partial write without transactions leaves
+	// orphaned data rather than versions pointing at nothing
+	if err = keystore.ApplyOperations(e.be, append([]keystore.Operation{metaOp}, deletes...)); err != nil {
+		return nil, err
+	}
+	return destroyed, nil
+}
+
+// GetMetadata returns the metadata of a secret.
+func (e *Engine) GetMetadata(path string) (*Metadata, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return e.readMetadata(path)
+}
+
+// UpdateMetadata changes the settings of a secret, creating its metadata if
+// the path has never been written so settings can be applied up front. A
+// max versions of zero falls back to the engine default.
+func (e *Engine) UpdateMetadata(path string, update MetadataUpdate) (*Metadata, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if update.MaxVersions != nil && *update.MaxVersions < 0 {
+		return nil, fmt.Errorf("max versions cannot be negative")
+	}
+
+	unlock, err := e.lockPath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	now := time.Now().UTC()
+	meta, err := e.readMetadata(path)
+	if errors.Is(err, ErrSecretNotFound) {
+		meta = newMetadata(now)
+	} else if err != nil {
+		return nil, err
+	}
+	if update.MaxVersions != nil {
+		meta.MaxVersions = *update.MaxVersions
+	}
+	if update.CASRequired != nil {
+		meta.CASRequired = *update.CASRequired
+	}
+	meta.UpdatedTime = now
+	pruned := e.prune(path, meta)
+	metaOp, err := metadataOperation(path, meta)
+	if err != nil {
+		return nil, err
+	}
+	if err = keystore.ApplyOperations(e.be, append([]keystore.Operation{metaOp}, pruned...)); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// Freeze runs fn while no write can go through the engine, for callers that
+// need a consistent view of the keystore.
+func (e *Engine) Freeze(fn func() error) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return fn()
+}
+
+// List returns the secrets and folders directly below prefix, which is
+// treated as a folder. Folders end in "/".
+func (e *Engine) List(prefix, cursor string, limit int) ([]string, string, error) {
+	prefix = strings.TrimPrefix(prefix, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return e.be.List(keystore.SECRETS_STORAGE, prefix, cursor, limit)
+}
+
+func (e *Engine) updateVersions(path string, versions []int64, defaultCurrent bool, update func(vm *VersionMetadata, now time.Time)) ([]int64, error) {
+	path, err := NormalizePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock, err := e.lockPath(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	meta, err := e.readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		if !defaultCurrent {
+			return nil, ErrNoVersions
+		}
+		versions = []int64{meta.CurrentVersion}
+	}
+	now := time.Now().UTC()
+	var updated []int64
+	for _, version := range versions {
+		vm, ok := meta.Versions[version]
+		if !ok || vm.Destroyed {
+			continue
+		}
+		update(vm, now)
+		updated = append(updated, version)
+	}
+	meta.UpdatedTime = now
+	return updated, e.writeMetadata(path, meta)
+}
+
+// prune drops the oldest versions beyond the configured maximum from meta
+// and returns the deletes for their data. It must be called with e.mu held.
+func (e *Engine) prune(path string, meta *Metadata) []keystore.Operation {
+	maxVersions := meta.MaxVersions
+	if maxVersions <= 0 {
+		maxVersions = e.maxVersions
+	}
+	var ops []keystore.Operation
+	for int64(len(meta.Versions)) > int64(maxVersions) {
+		oldest := meta.OldestVersion
+		if vm, ok := meta.Versions[oldest]; ok {
+			if !vm.Destroyed {
+				ops = append(ops, keystore.Operation{
+					Type:      keystore.DELETE_OPERATION,
+					StorageId: keystore.SECRET_VERSIONS_STORAGE,
+					Key:       versionKey(path, oldest),
+				})
+			}
+			delete(meta.Versions, oldest)
+		}
+		meta.OldestVersion++
+	}
+	return ops
+}
+
+func newMetadata(now time.Time) *Metadata {
+	return &Metadata{
+		CreatedTime:   now,
+		UpdatedTime:   now,
+		OldestVersion: 1,
+		Versions:      make(map[int64]*VersionMetadata),
+	}
+}
+
+func (e *Engine) readMetadata(path string) (*Metadata, error) {
+	value, err := e.be.Retrieve(keystore.SECRETS_STORAGE, path)
+	if errors.Is(err, keystore.ErrKeyNotFound) {
+		return nil, ErrSecretNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	var meta Metadata
+	if err = json.Unmarshal(value, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode secret metadata: %w", err)
+	}
+	if meta.Versions == nil {
+		meta.Versions = make(map[int64]*VersionMetadata)
+	}
+	return &meta, nil
+}
+
+func (e *Engine) writeMetadata(path string, meta *Metadata) error {
+	op, err := metadataOperation(path, meta)
+	if err != nil {
+		return err
+	}
+	return e.be.Store(op.StorageId, op.Key, op.Value)
+}
+
+func metadataOperation(path string, meta *Metadata) (keystore.Operation, error) {
+	value, err := json.Marshal(meta)
+	if err != nil {
+		return keystore.Operation{}, fmt.Errorf("failed to encode secret metadata: %w", err)
+	}
+	return keystore.Operation{
+		Type:      keystore.PUT_OPERATION,
+		StorageId: keystore.SECRETS_STORAGE,
+		Key:       path,
+		Value:     value,
+	}, nil
+}
+
+func versionKey(path string, version int64) string {
+	return fmt.Sprintf("%s/%d", path, version)
+}
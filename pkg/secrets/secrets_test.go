@@ -0,0 +1,401 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		err  error
+	}{
+		{"app/db", "app/db", nil},
+		{"/app/db/", "app/db", nil},
+		{"", "", ErrInvalidPath},
+		{"/", "", ErrInvalidPath},
+		{"app//db", "", ErrInvalidPath},
+		{"app/./db", "", ErrInvalidPath},
+		{"app/../db", "", ErrInvalidPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := NormalizePath(tt.path)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineList(t *testing.T) {
+	e := NewEngine(keystore.NewMemoryStore(), 0)
+	for _, path := range []string{"app/db", "app/api", "app/ci/token", "web"} {
+		if _, err := e.Put(path, map[string]string{"value": path}, nil); err != nil {
+			t.Fatalf("Put %s: %v", path, err)
+		}
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		cursor string
+		limit  int
+		want   []string
+		next   string
+	}{
+		{"root", "", "", 0, []string{"app/", "web"}, ""},
+		{"folder", "/app", "", 0, []string{"api", "ci/", "db"}, ""},
+		{"first page", "app/", "", 2, []string{"api", "ci/"}, "ci/"},
+		{"last page", "app/", "ci/", 2, []string{"db"}, ""},
+		{"missing folder", "none/", "", 0, []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, next, err := e.List(tt.prefix, tt.cursor, tt.limit)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || next != tt.next {
+				t.Fatalf("got %q, %q, want %q, %q", got, next, tt.want, tt.next)
+			}
+		})
+	}
+}
+
+// putVersions writes count versions of path, the nth holding "vn".
+func putVersions(t *testing.T, e *Engine, path string, count int) {
+	t.Helper()
+	for i := 1; i <= count; i++ {
+		version, err := e.Put(path, map[string]string{"value": fmt.Sprintf("v%d", i)}, nil)
+		if err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		if version != int64(i) {
+			t.Fatalf("Put returned version %d, want %d", version, i)
+		}
+	}
+}
+
+func TestEngineGet(t *testing.T) {
+	e := NewEngine(keystore.NewMemoryStore(), 0)
+	putVersions(t, e, "app/db", 3)
+	if _, err := e.Delete("app/db", []int64{2}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	tests := []struct {
+		name    string
+		path    string
+		version int64
+		value   string
+		err     error
+	}{
+		{"current version", "app/db", 0, "v3", nil},
+		{"older version", "app/db", 1, "v1", nil},
+		{"surrounding slashes", "/app/db/", 3, "v3", nil},
+		{"deleted version", "app/db", 2, "", ErrVersionDeleted},
+		{"missing version", "app/db", 4, "", ErrVersionNotFound},
+		{"missing secret", "app/api", 0, "", ErrSecretNotFound},
+		{"invalid path", "app/../db", 0, "", ErrInvalidPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret, err := e.Get(tt.path, tt.version)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && secret.Data["value"] != tt.value {
+				t.Fatalf("got %q, want %q", secret.Data["value"], tt.value)
+			}
+		})
+	}
+}
+
+func TestEnginePrune(t *testing.T) {
+	tests := []struct {
+		name        string
+		engineMax   int
+		secretMax   int
+		puts        int
+		oldest      int64
+		remaining   int
+		storedFirst int64
+	}{
+		{"below the limit", 3, 0, 2, 1, 2, 1},
+		{"engine limit", 3, 0, 5, 3, 3, 3},
+		{"secret limit", 3, 2, 5, 4, 2, 4},
+		{"secret limit above engine limit", 3, 4, 6, 3, 4, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := keystore.NewMemoryStore()
+			e := NewEngine(store, tt.engineMax)
+			putVersions(t, e, "app/db", 1)
+			if tt.secretMax > 0 {
+				if _, err := e.UpdateMetadata("app/db", MetadataUpdate{MaxVersions: &tt.secretMax}); err != nil {
+					t.Fatalf("UpdateMetadata: %v", err)
+				}
+			}
+			for i := 2; i <= tt.puts; i++ {
+				if _, err := e.Put("app/db", map[string]string{"value": fmt.Sprintf("v%d", i)}, nil); err != nil {
+					t.Fatalf("Put: %v", err)
+				}
+			}
+			meta, err := e.GetMetadata("app/db")
+			if err != nil {
+				t.Fatalf("GetMetadata: %v", err)
+			}
+			if meta.OldestVersion != tt.oldest || len(meta.Versions) != tt.remaining {
+				t.Fatalf("got oldest %d and %d versions, want %d and %d", meta.OldestVersion, len(meta.Versions), tt.oldest, tt.remaining)
+			}
+			for version := int64(1); version <= int64(tt.puts); version++ {
+				_, err := store.Retrieve(keystore.SECRET_VERSIONS_STORAGE, versionKey("app/db", version))
+				if stored := err == nil; stored != (version >= tt.storedFirst) {
+					t.Fatalf("version %d stored: %v", version, stored)
+				}
+			}
+		})
+	}
+}
+
+func TestEngineUpdateMetadataPrunes(t *testing.T) {
+	e := NewEngine(keystore.NewMemoryStore(), 0)
+	putVersions(t, e, "app/db", 5)
+	maxVersions := 2
+	meta, err := e.UpdateMetadata("app/db", MetadataUpdate{MaxVersions: &maxVersions})
+	if err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	if meta.OldestVersion != 4 || len(meta.Versions) != 2 {
+		t.Fatalf("got oldest %d and %d versions, want 4 and 2", meta.OldestVersion, len(meta.Versions))
+	}
+	if _, err = e.Get("app/db", 3); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("Get of a pruned version: got %v, want %v", err, ErrVersionNotFound)
+	}
+	maxVersions = -1
+	if _, err = e.UpdateMetadata("app/db", MetadataUpdate{MaxVersions: &maxVersions}); err == nil {
+		t.Fatalf("UpdateMetadata accepted a negative max versions")
+	}
+}
+
+func TestEngineDeleteUndelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		delete   []int64
+		undelete []int64
+		deleted  []int64
+		restored []int64
+		readable []int64
+		undelErr error
+	}{
+		{"current version by default", nil, []int64{3}, []int64{3}, []int64{3}, []int64{1, 2, 3}, nil},
+		{"selected versions", []int64{1, 2}, []int64{1}, []int64{1, 2}, []int64{1}, []int64{1, 3}, nil},
+		{"missing versions are skipped", []int64{2, 9}, []int64{2, 9}, []int64{2}, []int64{2}, []int64{1, 2, 3}, nil},
+		{"undelete needs versions", []int64{1}, nil, []int64{1}, nil, []int64{2, 3}, ErrNoVersions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(keystore.NewMemoryStore(), 0)
+			putVersions(t, e, "app/db", 3)
+			deleted, err := e.Delete("app/db", tt.delete)
+			if err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if !reflect.DeepEqual(deleted, tt.deleted) {
+				t.Fatalf("deleted %v, want %v", deleted, tt.deleted)
+			}
+			restored, err := e.Undelete("app/db", tt.undelete)
+			if !errors.Is(err, tt.undelErr) {
+				t.Fatalf("Undelete: got %v, want %v", err, tt.undelErr)
+			}
+			if !reflect.DeepEqual(restored, tt.restored) {
+				t.Fatalf("restored %v, want %v", restored, tt.restored)
+			}
+			checkReadable(t, e, "app/db", 3, tt.readable)
+		})
+	}
+}
+
+func TestEngineDestroy(t *testing.T) {
+	store := keystore.NewMemoryStore()
+	e := NewEngine(store, 0)
+	putVersions(t, e, "app/db", 3)
+	tests := []struct {
+		name      string
+		versions  []int64
+		destroyed []int64
+		err       error
+	}{
+		{"selected versions", []int64{1, 2}, []int64{1, 2}, nil},
+		{"destroyed versions are skipped", []int64{2, 3}, []int64{3}, nil},
+		{"versions are required", nil, nil, ErrNoVersions},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destroyed, err := e.Destroy("app/db", tt.versions)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(destroyed, tt.destroyed) {
+				t.Fatalf("destroyed %v, want %v", destroyed, tt.destroyed)
+			}
+		})
+	}
+	for version := int64(1); version <= 3; version++ {
+		if _, err := store.Retrieve(keystore.SECRET_VERSIONS_STORAGE, versionKey("app/db", version)); err == nil {
+			t.Fatalf("data of destroyed version %d is still stored", version)
+		}
+	}
+	// destroyed versions cannot be restored
+	if restored, _ := e.Undelete("app/db", []int64{1}); len(restored) != 0 {
+		t.Fatalf("undeleted destroyed versions %v", restored)
+	}
+	checkReadable(t, e, "app/db", 3, nil)
+}
+
+func TestEnginePutCAS(t *testing.T) {
+	version := func(v int64) *int64 { return &v }
+	tests := []struct {
+		name        string
+		existing    int
+		casRequired bool
+		cas         *int64
+		want        int64
+		err         error
+	}{
+		{"no cas", 2, false, nil, 3, nil},
+		{"matching cas", 2, false, version(2), 3, nil},
+		{"cas mismatch", 2, false, version(1), 0, ErrCASMismatch},
+		{"cas ahead of the current version", 2, false, version(3), 0, ErrCASMismatch},
+		{"cas 0 on a new secret", 0, false, version(0), 1, nil},
+		{"cas 0 on an existing secret", 2, false, version(0), 0, ErrCASMismatch},
+		{"cas required and missing", 2, true, nil, 0, ErrCASRequired},
+		{"cas required and matching", 2, true, version(2), 3, nil},
+		{"cas required on a new secret", 0, true, nil, 0, ErrCASRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEngine(keystore.NewMemoryStore(), 0)
+			putVersions(t, e, "app/db", tt.existing)
+			if tt.casRequired {
+				if _, err := e.UpdateMetadata("app/db", MetadataUpdate{CASRequired: &tt.casRequired}); err != nil {
+					t.Fatalf("UpdateMetadata: %v", err)
+				}
+			}
+			got, err := e.Put("app/db", map[string]string{"value": "new"}, tt.cas)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("got version %d, want %d", got, tt.want)
+			}
+			meta, err := e.GetMetadata("app/db")
+			if err != nil {
+				t.Fatalf("GetMetadata: %v", err)
+			}
+			current := int64(tt.existing)
+			if tt.err == nil {
+				current = tt.want
+			}
+			if meta.CurrentVersion != current {
+				t.Fatalf("current version %d, want %d", meta.CurrentVersion, current)
+			}
+		})
+	}
+}
+
+func TestEngineUpdateMetadataNewSecret(t *testing.T) {
+	e := NewEngine(keystore.NewMemoryStore(), 0)
+	casRequired := true
+	if _, err := e.UpdateMetadata("app/db", MetadataUpdate{CASRequired: &casRequired}); err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	meta, err := e.GetMetadata("app/db")
+	if err != nil {
+		t.Fatalf("GetMetadata: %v", err)
+	}
+	if !meta.CASRequired || meta.CurrentVersion != 0 {
+		t.Fatalf("got cas required %v and version %d, want true and 0", meta.CASRequired, meta.CurrentVersion)
+	}
+	if _, err = e.Get("app/db", 0); !errors.Is(err, ErrVersionNotFound) {
+		t.Fatalf("Get: got %v, want %v", err, ErrVersionNotFound)
+	}
+}
+
+// checkReadable fails unless exactly the versions in readable, out of
+// versions 1 to count, can be read.
+func checkReadable(t *testing.T, e *Engine, path string, count int64, readable []int64) {
+	t.Helper()
+	want := make(map[int64]bool)
+	for _, version := range readable {
+		want[version] = true
+	}
+	for version := int64(1); version <= count; version++ {
+		_, err := e.Get(path, version)
+		if want[version] && err != nil {
+			t.Fatalf("Get version %d: %v", version, err)
+		}
+		if !want[version] && !errors.Is(err, ErrVersionDeleted) {
+			t.Fatalf("Get version %d: got %v, want %v", version, err, ErrVersionDeleted)
+		}
+	}
+}
+
+// recordingLocker records the paths locked and whether they were released.
+type recordingLocker struct {
+	err    error
+	locked []string
+	held   int
+}
+
+func (l *recordingLocker) LockPath(path string) (func(), error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	l.locked = append(l.locked, path)
+	l.held++
+	return func() { l.held-- }, nil
+}
+
+func TestEnginePathLocker(t *testing.T) {
+	locker := &recordingLocker{}
+	var invalidated []string
+	e := NewEngine(keystore.NewMemoryStore(), 0)
+	e.SetPathLocker(locker, func(storageId, key string) {
+		invalidated = append(invalidated, storageId+"/"+key)
+	})
+	if _, err := e.Put("app/db", map[string]string{"value": "v1"}, nil); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := e.Delete("app/db", nil); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := e.Get("app/db", 1); !errors.Is(err, ErrVersionDeleted) {
+		t.Fatalf("Get: got %v, want %v", err, ErrVersionDeleted)
+	}
+	// reads do not take the lock
+	want := []string{"app/db", "app/db"}
+	if !reflect.DeepEqual(locker.locked, want) {
+		t.Fatalf("locked %q, want %q", locker.locked, want)
+	}
+	if locker.held != 0 {
+		t.Fatalf("%d locks were not released", locker.held)
+	}
+	wantInvalidated := []string{keystore.SECRETS_STORAGE + "/app/db", keystore.SECRETS_STORAGE + "/app/db"}
+	if !reflect.DeepEqual(invalidated, wantInvalidated) {
+		t.Fatalf("invalidated %q, want %q", invalidated, wantInvalidated)
+	}
+
+	errLocked := errors.New("locked")
+	locker.err = errLocked
+	if _, err := e.Put("app/db", map[string]string{"value": "v2"}, nil); !errors.Is(err, errLocked) {
+		t.Fatalf("Put while locked: got %v, want %v", err, errLocked)
+	}
+}
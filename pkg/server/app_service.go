@@ -2,26 +2,41 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/skriptvalley/keyhouse/pkg/keystore"
 	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
 	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// DEFAULT_STATE_HISTORY_LIMIT is the number of transitions GetStateHistory
+// returns when the request sets no limit.
+const DEFAULT_STATE_HISTORY_LIMIT = 50
+
 type AppServer struct {
 	app.UnimplementedAppServer
 	appVersion string
 	be         keystore.BackendKeyStore
+	physical   keystore.BackendKeyStore
+	cache      keystore.CachedKeyStore
 	sm         *statemanager.StateManager
+	secrets    *secrets.Engine
+	// schedule of automatic snapshots, empty when disabled
+	snapshotSchedule string
 }
 
 // GetStatus returns the status of the service
 func (s *AppServer) GetStatus(ctx context.Context, req *app.StatusRequest) (*app.StatusResponse, error) {
-	status, err := s.sm.DB.GetVaultState(ctx)
+	status, err := s.sm.NodeState(ctx)
 	if err != nil {
 		return &app.StatusResponse{
 			Service:   "KeyHouse",
@@ -30,79 +45,154 @@ func (s *AppServer) GetStatus(ctx context.Context, req *app.StatusRequest) (*app
 			Timestamp: timestamppb.New(time.Now()),
 		}, err
 	}
-	return &app.StatusResponse{
+	resp := &app.StatusResponse{
 		Service:   "KeyHouse",
 		Version:   s.appVersion,
-		Status:    status,
+		Status:    string(status),
 		Timestamp: timestamppb.New(time.Now()),
-	}, nil
+		Cache:     s.cacheStats(),
+		Snapshot:  s.snapshotStatus(ctx),
+	}
+	if progress, err := s.sm.UnsealProgress(ctx); err == nil {
+		resp.Threshold = int32(progress.Threshold)
+		resp.Progress = int32(progress.Progress)
+		resp.Nonce = progress.Nonce
+	}
+	return resp, nil
+}
+
+func (s *AppServer) snapshotStatus(ctx context.Context) *app.SnapshotStatus {
+	status, err := s.sm.DB.GetSnapshotStatus(ctx)
+	if err != nil {
+		return nil
+	}
+	if s.snapshotSchedule == "" && status.LastSuccess.IsZero() && status.LastFailure.IsZero() {
+		return nil
+	}
+	pbStatus := &app.SnapshotStatus{
+		Schedule:     s.snapshotSchedule,
+		LastSnapshot: status.LastSnapshot,
+		LastError:    status.LastError,
+	}
+	if !status.LastSuccess.IsZero() {
+		pbStatus.LastSuccess = timestamppb.New(status.LastSuccess)
+	}
+	if !status.LastFailure.IsZero() {
+		pbStatus.LastFailure = timestamppb.New(status.LastFailure)
+	}
+	return pbStatus
+}
+
+func (s *AppServer) cacheStats() *app.CacheStats {
+	if s.cache == nil {
+		return nil
+	}
+	stats := s.cache.Stats()
+	return &app.CacheStats{
+		Hits:     stats.Hits,
+		Misses:   stats.Misses,
+		Entries:  int32(stats.Entries),
+		Capacity: int32(stats.Capacity),
+	}
 }
 
 func (s *AppServer) InitKeyhouse(ctx context.Context, req *app.InitRequest) (*app.InitResponse, error) {
-	var resp *app.InitResponse
-	var err error
-	if s.sm.IsVaultReady(ctx) {
-		resp, err = &app.InitResponse{
-			Status:     statemanager.VAULT_STATE_READY,
+	state, err := s.sm.VaultState(ctx)
+	if err != nil {
+		return &app.InitResponse{
+			Status:     "unknown",
+			Message:    "failed to get vault state",
+			Keyholders: nil,
+		}, status.Error(codes.Internal, "failed to get vault state")
+	}
+	switch {
+	case state.Unsealed():
+		return &app.InitResponse{
+			Status:     string(state),
 			Message:    "vault is initialized and ready",
 			Keyholders: nil,
 		}, nil
-	} else if s.sm.IsVaultLocked(ctx) {
-		active_keys, err := s.sm.DB.GetActiveKeysCount(ctx)
-		if err != nil {
-			return &app.InitResponse{
-				Status:     statemanager.VAULT_STATE_LOCKED,
-				Message:    "failed to fetch active keys count",
-				Keyholders: nil,
-			}, err
-		}
-		resp, err = &app.InitResponse{
-			Status:     statemanager.VAULT_STATE_LOCKED,
-			Message:    fmt.Sprintf("vault is locked. need %v keyholders to activate keyhouse", 3-active_keys),
+	case state.Sealed():
+		return &app.InitResponse{
+			Status:     string(state),
+			Message:    "vault is initialized and locked. see the status for unseal progress",
 			Keyholders: nil,
 		}, nil
-	} else if s.sm.IsVaultDown(ctx) {
-		err = s.sm.GenerateKeys(ctx)
-		if err != nil {
-			return &app.InitResponse{
-				Status:     "unknown",
-				Message:    "failed to generate keys",
-				Keyholders: nil,
-			}, err
-		}
-		keyholdersMap, err := s.sm.DB.GetKeyholders(ctx)
-		if err != nil {
-			return &app.InitResponse{
-				Status:     "unknown",
-				Message:    "failed to fetch keyholders",
-				Keyholders: nil,
-			}, err
-		}
-		var keyholders []string
-		for keyholder := range keyholdersMap {
-			keyholders = append(keyholders, keyholder)
-		}
-		resp = &app.InitResponse{
-			Status:     statemanager.VAULT_STATE_LOCKED,
-			Message:    "vault is initialized. please distribute generated keys to different individuals over private channel",
-			Keyholders: keyholders,
-		}
-	} else {
-		resp, err = &app.InitResponse{
+	case state == statemanager.VAULT_STATE_INITIALIZING:
+		return &app.InitResponse{
+			Status:     string(state),
+			Message:    "another replica is initializing the vault. check the status before retrying",
+			Keyholders: nil,
+		}, status.Error(codes.Aborted, "vault is being initialized")
+	case state != statemanager.VAULT_STATE_DOWN:
+		return &app.InitResponse{
 			Status:     "unknown",
 			Message:    "vault is in unknown state",
 			Keyholders: nil,
 		}, fmt.Errorf("internal server error: %v", http.StatusInternalServerError)
 	}
-	return resp, err
+
+	shares, threshold := int(req.GetSecretShares()), int(req.GetSecretThreshold())
+	if shares < 1 || threshold < 1 || threshold > shares {
+		return &app.InitResponse{
+			Status:     string(statemanager.VAULT_STATE_DOWN),
+			Message:    "secret_shares and secret_threshold are required, with threshold not exceeding shares",
+			Keyholders: nil,
+		}, status.Error(codes.InvalidArgument, "invalid secret shares or threshold")
+	}
+	keyholders, rootToken, err := s.sm.InitVault(withActor(ctx, "init-code"), req.GetCode(), shares, threshold)
+	if isVaultBusy(err) || errors.Is(err, statemanager.ErrVaultInitialized) {
+		return &app.InitResponse{
+			Status:     "unknown",
+			Message:    "another replica is initializing the vault. check the status before retrying",
+			Keyholders: nil,
+		}, status.Error(codes.Aborted, err.Error())
+	} else if codeErr := initCodeError(err); codeErr != nil {
+		return &app.InitResponse{
+			Status:     string(statemanager.VAULT_STATE_DOWN),
+			Message:    err.Error(),
+			Keyholders: nil,
+		}, codeErr
+	} else if err != nil {
+		return &app.InitResponse{
+			Status:     "unknown",
+			Message:    "failed to generate keys. retry with the same init code",
+			Keyholders: nil,
+		}, err
+	}
+	return &app.InitResponse{
+		Status:     string(statemanager.VAULT_STATE_LOCKED),
+		Message:    "vault is initialized. please distribute generated keys to different individuals over private channel",
+		Keyholders: keyholders,
+		RootToken:  rootToken,
+	}, nil
 }
 
 func (s *AppServer) ActivateKey(ctx context.Context, req *app.ActivateKeyRequest) (*app.ActivateKeyResponse, error) {
 	var resp *app.ActivateKeyResponse
 	var err error
-	keyholder := fmt.Sprintf("%v:%v", statemanager.KEY_PREFIX, req.GetKeyholder())
-	is_ready, err := s.sm.UnlockVault(ctx, keyholder)
-	if err != nil {
+	is_ready, err := s.sm.UnlockVault(withActor(ctx, "keyholder"), req.GetKeyholder(), req.GetNonce())
+	if errors.Is(err, statemanager.ErrUnsealNonceMismatch) {
+		return &app.ActivateKeyResponse{
+			Status:  s.vaultState(ctx),
+			Message: "the unseal attempt was reset. submit the key again without a nonce or with the current one",
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	} else if isVaultBusy(err) {
+		return &app.ActivateKeyResponse{
+			Status:  s.vaultState(ctx),
+			Message: "key activated, but another vault operation is in progress. submit the key again to finish unsealing",
+		}, status.Error(codes.Aborted, err.Error())
+	} else if errors.Is(err, statemanager.ErrSharesOnOtherNodes) {
+		return &app.ActivateKeyResponse{
+			Status:  s.vaultState(ctx),
+			Message: "key activated, but the shares were spread over several nodes. submit the keys again to node " + s.sm.NodeID() + " until it reaches the threshold",
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	} else if errors.Is(err, keystore.ErrSealDigestMismatch) {
+		return &app.ActivateKeyResponse{
+			Status:  s.vaultState(ctx),
+			Message: "the seal config does not match the keystore. restore a trusted snapshot with an init code from keyhouse operator rotate-init-code",
+		}, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
 		return &app.ActivateKeyResponse{
 			Status:  "unknown",
 			Message: "failed to activate key",
@@ -110,14 +200,133 @@ func (s *AppServer) ActivateKey(ctx context.Context, req *app.ActivateKeyRequest
 	}
 	if is_ready {
 		resp = &app.ActivateKeyResponse{
-			Status:  statemanager.VAULT_STATE_READY,
+			Status:  string(statemanager.VAULT_STATE_READY),
 			Message: "vault is activated",
 		}
 	} else {
 		resp = &app.ActivateKeyResponse{
-			Status:  statemanager.VAULT_STATE_LOCKED,
+			Status:  s.vaultState(ctx),
 			Message: "key activated",
 		}
 	}
+	if progress, err := s.sm.UnsealProgress(ctx); err == nil {
+		resp.Threshold = int32(progress.Threshold)
+		resp.Progress = int32(progress.Progress)
+		resp.Nonce = progress.Nonce
+	}
 	return resp, err
 }
+
+// ResetUnseal discards the unseal key shares submitted so far, so a
+// half-done unseal can start over.
+func (s *AppServer) ResetUnseal(ctx context.Context, req *app.ResetUnsealRequest) (*app.ResetUnsealResponse, error) {
+	err := s.sm.ResetUnseal(withActor(ctx, "keyholder"))
+	if errors.Is(err, statemanager.ErrVaultNotSealed) {
+		return nil, status.Error(codes.FailedPrecondition, "vault is not locked")
+	} else if isVaultBusy(err) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "failed to reset unseal")
+	}
+	return &app.ResetUnsealResponse{
+		Status:  s.vaultState(ctx),
+		Message: "unseal attempt reset. keyholders must activate their keys again",
+	}, nil
+}
+
+// initCodeError maps a rejected init code to a grpc error, and returns nil
+// for any other error.
+func initCodeError(err error) error {
+	switch {
+	case errors.Is(err, statemanager.ErrInvalidInitCode):
+		return status.Error(codes.PermissionDenied, "invalid init code")
+	case errors.Is(err, statemanager.ErrInitCodeExpired):
+		return status.Error(codes.PermissionDenied, "init code has expired, rotate it with keyhouse operator rotate-init-code")
+	case errors.Is(err, statemanager.ErrTooManyInitAttempts):
+		return status.Error(codes.ResourceExhausted, "too many failed init attempts, try again later or rotate the init code")
+	default:
+		return nil
+	}
+}
+
+// vaultState returns the vault state of this node reported in responses.
+func (s *AppServer) vaultState(ctx context.Context) string {
+	state, err := s.sm.NodeState(ctx)
+	if err != nil {
+		return "unknown"
+	}
+	return string(state)
+}
+
+// withActor attributes the vault state changes made by a call to the
+// credential it presented and the address of the client.
+func withActor(ctx context.Context, credential string) context.Context {
+	addr := "unknown"
+	md, _ := metadata.FromIncomingContext(ctx)
+	if client := md.Get(CLIENT_ADDR_METADATA); len(client) > 0 {
+		// only ever set by the http gateway, see clientAddr
+		addr = client[len(client)-1]
+	} else if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	return statemanager.WithActor(ctx, credential+"@"+addr)
+}
+
+// GetStateHistory returns the latest vault state transitions.
+func (s *AppServer) GetStateHistory(ctx context.Context, req *app.StateHistoryRequest) (*app.StateHistoryResponse, error) {
+	if err := s.authorizeRoot(ctx); err != nil {
+		return nil, err
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = DEFAULT_STATE_HISTORY_LIMIT
+	}
+	history, err := s.sm.StateHistory(ctx, limit)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to read state history")
+	}
+	resp := &app.StateHistoryResponse{
+		Transitions: make([]*app.StateTransition, 0, len(history)),
+	}
+	for _, t := range history {
+		resp.Transitions = append(resp.Transitions, &app.StateTransition{
+			Timestamp: timestamppb.New(t.Time),
+			From:      string(t.From),
+			To:        string(t.To),
+			Actor:     t.Actor,
+			Reason:    t.Reason,
+			Node:      t.Node,
+		})
+	}
+	return resp, nil
+}
+
+// isVaultBusy reports whether err means another replica won the race for
+// the vault lock. Callers get codes.Aborted and may retry.
+func isVaultBusy(err error) bool {
+	return errors.Is(err, statemanager.ErrVaultBusy) || errors.Is(err, statemanager.ErrVaultLockLost)
+}
+
+// Seal locks the vault again. Every replica refuses access until the
+// keyholders unlock it.
+func (s *AppServer) Seal(ctx context.Context, req *app.SealRequest) (*app.SealResponse, error) {
+	if err := s.authorizeRoot(ctx); err != nil {
+		return nil, err
+	}
+	reason := req.GetReason()
+	if reason == "" {
+		reason = "sealed by operator"
+	}
+	err := s.sm.SealVault(withActor(ctx, "root-token"), reason)
+	if errors.Is(err, statemanager.ErrVaultNotInitialized) {
+		return nil, status.Error(codes.FailedPrecondition, "vault is not initialized")
+	} else if isVaultBusy(err) {
+		return nil, status.Error(codes.Aborted, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "failed to seal vault")
+	}
+	return &app.SealResponse{
+		Status:  s.vaultState(ctx),
+		Message: "vault is sealed. keyholders must unlock it again",
+	}, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetStateHistory(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAppServer(t)
+	code, err := s.sm.GetInitCode(ctx)
+	if err != nil {
+		t.Fatalf("GetInitCode: %v", err)
+	}
+
+	// the history is refused even before the vault has a root token
+	if _, err = s.GetStateHistory(ctx, &app.StateHistoryRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("GetStateHistory while down: got %v, want %v", err, codes.Unauthenticated)
+	}
+
+	// the init request is attributed to the client address set by the
+	// gateway, not to the peer
+	initCtx := peer.NewContext(
+		metadata.NewIncomingContext(ctx, metadata.Pairs(CLIENT_ADDR_METADATA, "10.0.0.7:4242")),
+		&peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}},
+	)
+	_, rootToken, err := s.sm.InitVault(withActor(initCtx, "init-code"), code, 1, 1)
+	if err != nil {
+		t.Fatalf("InitVault: %v", err)
+	}
+
+	resp, err := s.GetStateHistory(withHeader(ROOT_TOKEN_HEADER, rootToken), &app.StateHistoryRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetStateHistory: %v", err)
+	}
+	if len(resp.Transitions) != 1 {
+		t.Fatalf("got %d transitions, want 1", len(resp.Transitions))
+	}
+	if actor := resp.Transitions[0].Actor; actor != "init-code@10.0.0.7:4242" {
+		t.Fatalf("got actor %q, want %q", actor, "init-code@10.0.0.7:4242")
+	}
+}
+
+func TestWithActor(t *testing.T) {
+	ctx := context.Background()
+	grpcPeer := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 5000}}
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		actor string
+	}{
+		{"gateway client", metadata.NewIncomingContext(ctx, metadata.Pairs(CLIENT_ADDR_METADATA, "10.0.0.7:4242")), "root-token@10.0.0.7:4242"},
+		{"grpc peer", peer.NewContext(ctx, grpcPeer), "root-token@192.168.1.2:5000"},
+		{"no address", ctx, "root-token@unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAppServer(t)
+			shares, rootToken, err := s.sm.GenerateKeys(ctx, 1, 1)
+			if err != nil {
+				t.Fatalf("GenerateKeys: %v", err)
+			}
+			if _, err = s.sm.UnlockVault(ctx, shares[0], ""); err != nil {
+				t.Fatalf("UnlockVault: %v", err)
+			}
+			if err = s.sm.SealVault(withActor(tt.ctx, "root-token"), "test"); err != nil {
+				t.Fatalf("SealVault: %v", err)
+			}
+			resp, err := s.GetStateHistory(withHeader(ROOT_TOKEN_HEADER, rootToken), &app.StateHistoryRequest{Limit: 1})
+			if err != nil {
+				t.Fatalf("GetStateHistory: %v", err)
+			}
+			if actor := resp.Transitions[0].Actor; actor != tt.actor {
+				t.Fatalf("got actor %q, want %q", actor, tt.actor)
+			}
+		})
+	}
+}
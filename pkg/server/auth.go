@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ROOT_TOKEN_HEADER carries the root token of privileged calls.
+const ROOT_TOKEN_HEADER = "x-keyhouse-token"
+
+// INIT_CODE_HEADER carries the init code of calls that are allowed before
+// the vault has a root token.
+const INIT_CODE_HEADER = "x-keyhouse-init-code"
+
+// authorizeRoot checks the root token sent with a privileged call.
+func (s *AppServer) authorizeRoot(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(ROOT_TOKEN_HEADER)
+	if len(tokens) == 0 || tokens[0] == "" {
+		return status.Error(codes.Unauthenticated, "root token is required")
+	}
+	err := s.sm.VerifyRootToken(ctx, tokens[0])
+	if errors.Is(err, statemanager.ErrInvalidRootToken) {
+		return status.Error(codes.PermissionDenied, "invalid root token")
+	} else if err != nil {
+		return status.Error(codes.Internal, "failed to verify root token")
+	}
+	return nil
+}
+
+// authorizeInitCode checks the init code sent with a call made while the
+// vault is down and returns it. The call consumes it with ConsumeInitCode
+// once it has succeeded.
+func (s *AppServer) authorizeInitCode(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(INIT_CODE_HEADER)
+	if len(values) == 0 || values[0] == "" {
+		return "", status.Error(codes.Unauthenticated, "init code is required")
+	}
+	err := s.sm.CheckInitCode(ctx, values[0])
+	if grpcErr := initCodeError(err); grpcErr != nil {
+		return "", grpcErr
+	} else if err != nil {
+		return "", status.Error(codes.Internal, "failed to verify init code")
+	}
+	return values[0], nil
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestAppServer(t *testing.T) *AppServer {
+	t.Helper()
+	physical := keystore.NewMemoryStore()
+	barrier := keystore.NewBarrier(physical)
+	sm := statemanager.NewStateManagerWithDB(zap.NewNop(), statemanager.NewMemoryDB(zap.NewNop()))
+	sm.SetBarrier(barrier)
+	if err := sm.InitStateDBCache(context.Background()); err != nil {
+		t.Fatalf("InitStateDBCache: %v", err)
+	}
+	return &AppServer{
+		sm:       sm,
+		be:       barrier,
+		physical: physical,
+		secrets:  secrets.NewEngine(barrier, 0),
+	}
+}
+
+// withHeader returns a context carrying header as incoming metadata, or no
+// metadata at all when value is empty.
+func withHeader(header, value string) context.Context {
+	if value == "" {
+		return context.Background()
+	}
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(header, value))
+}
+
+func TestAuthorizeRoot(t *testing.T) {
+	s := newTestAppServer(t)
+	_, rootToken, err := s.sm.GenerateKeys(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{"root token", rootToken, codes.OK},
+		{"missing token", "", codes.Unauthenticated},
+		{"wrong token", "wrong", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.authorizeRoot(withHeader(ROOT_TOKEN_HEADER, tt.token))
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("got %v, want %v", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestAuthorizeInitCode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		prepare func(s *AppServer, initCode string) string
+		want    codes.Code
+	}{
+		{"init code", func(s *AppServer, initCode string) string { return initCode }, codes.OK},
+		{"missing code", func(s *AppServer, initCode string) string { return "" }, codes.Unauthenticated},
+		{"wrong code", func(s *AppServer, initCode string) string { return "wrong" }, codes.PermissionDenied},
+		{"consumed code", func(s *AppServer, initCode string) string {
+			s.sm.ConsumeInitCode(ctx, initCode)
+			return initCode
+		}, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestAppServer(t)
+			initCode, err := s.sm.GetInitCode(ctx)
+			if err != nil {
+				t.Fatalf("GetInitCode: %v", err)
+			}
+			md := withHeader(INIT_CODE_HEADER, tt.prepare(s, initCode))
+			code, err := s.authorizeInitCode(md)
+			if status.Code(err) != tt.want {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if code != initCode {
+				t.Fatalf("got init code %q, want %q", code, initCode)
+			}
+			// the code is left for the call to consume once it succeeded
+			if _, err = s.authorizeInitCode(md); err != nil {
+				t.Fatalf("second call: %v", err)
+			}
+		})
+	}
+}
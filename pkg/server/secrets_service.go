@@ -0,0 +1,265 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 100
+	MAX_PAGE_SIZE     = 1000
+	// ErrorInfo reason marking failed check-and-set writes
+	CAS_FAILED_REASON = "CAS_FAILED"
+)
+
+// PutSecret writes a new version of a secret
+func (s *AppServer) PutSecret(ctx context.Context, req *app.PutSecretRequest) (*app.PutSecretResponse, error) {
+	if err := s.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	version, err := s.secrets.Put(path, req.GetData(), req.Cas)
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.PutSecretResponse{Path: path, Version: version}, nil
+}
+
+// GetSecret returns a version of a secret
+func (s *AppServer) GetSecret(ctx context.Context, req *app.GetSecretRequest) (*app.GetSecretResponse, error) {
+	if err := s.checkVaultReady(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	secret, err := s.secrets.Get(path, req.GetVersion())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.GetSecretResponse{
+		Path:     path,
+		Data:     secret.Data,
+		Metadata: versionMetadataToProto(secret.Version, secret.Metadata),
+	}, nil
+}
+
+// DeleteSecret soft-deletes versions of a secret
+func (s *AppServer) DeleteSecret(ctx context.Context, req *app.DeleteSecretRequest) (*app.DeleteSecretResponse, error) {
+	if err := s.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	versions, err := s.secrets.Delete(path, req.GetVersions())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.DeleteSecretResponse{Path: path, Versions: versions}, nil
+}
+
+// UndeleteSecret restores soft-deleted versions of a secret
+func (s *AppServer) UndeleteSecret(ctx context.Context, req *app.UndeleteSecretRequest) (*app.UndeleteSecretResponse, error) {
+	if err := s.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	versions, err := s.secrets.Undelete(path, req.GetVersions())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.UndeleteSecretResponse{Path: path, Versions: versions}, nil
+}
+
+// DestroySecret permanently removes versions of a secret
+func (s *AppServer) DestroySecret(ctx context.Context, req *app.DestroySecretRequest) (*app.DestroySecretResponse, error) {
+	if err := s.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	versions, err := s.secrets.Destroy(path, req.GetVersions())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.DestroySecretResponse{Path: path, Versions: versions}, nil
+}
+
+// GetSecretMetadata returns the metadata and version history of a secret
+func (s *AppServer) GetSecretMetadata(ctx context.Context, req *app.GetSecretMetadataRequest) (*app.SecretMetadata, error) {
+	if err := s.checkVaultReady(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	meta, err := s.secrets.GetMetadata(path)
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return metadataToProto(path, meta), nil
+}
+
+// UpdateSecretMetadata changes the settings of a secret
+func (s *AppServer) UpdateSecretMetadata(ctx context.Context, req *app.UpdateSecretMetadataRequest) (*app.SecretMetadata, error) {
+	if err := s.checkWritable(ctx); err != nil {
+		return nil, err
+	}
+	path, err := secrets.NormalizePath(req.GetPath())
+	if err != nil {
+		return nil, secretError(err)
+	}
+	update := secrets.MetadataUpdate{CASRequired: req.CasRequired}
+	if req.MaxVersions != nil {
+		maxVersions := int(req.GetMaxVersions())
+		update.MaxVersions = &maxVersions
+	}
+	meta, err := s.secrets.UpdateMetadata(path, update)
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return metadataToProto(path, meta), nil
+}
+
+// ListSecrets returns a page of the secrets and sub-folders in a folder
+func (s *AppServer) ListSecrets(ctx context.Context, req *app.ListSecretsRequest) (*app.ListSecretsResponse, error) {
+	if err := s.checkVaultReady(ctx); err != nil {
+		return nil, err
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size cannot be negative")
+	}
+	if pageSize == 0 {
+		pageSize = DEFAULT_PAGE_SIZE
+	}
+	if pageSize > MAX_PAGE_SIZE {
+		pageSize = MAX_PAGE_SIZE
+	}
+	keys, next, err := s.secrets.List(req.GetPrefix(), req.GetPageToken(), pageSize)
+	if err != nil {
+		return nil, secretError(err)
+	}
+	return &app.ListSecretsResponse{Keys: keys, NextPageToken: next}, nil
+}
+
+func (s *AppServer) checkVaultReady(ctx context.Context) error {
+	if !s.sm.IsVaultReady(ctx) {
+		return status.Error(codes.Unavailable, "vault is sealed")
+	}
+	return nil
+}
+
+// checkWritable refuses writes while the vault is sealed or an operator has
+// put keyhouse in maintenance mode.
+func (s *AppServer) checkWritable(ctx context.Context) error {
+	if err := s.checkVaultReady(ctx); err != nil {
+		return err
+	}
+	maintenance, err := s.sm.IsInMaintenance(ctx)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to check maintenance mode")
+	}
+	if maintenance {
+		return status.Error(codes.Unavailable, "keyhouse is in maintenance mode")
+	}
+	return nil
+}
+
+func versionMetadataToProto(version int64, vm *secrets.VersionMetadata) *app.SecretVersionMetadata {
+	pbVersion := &app.SecretVersionMetadata{
+		Version:     version,
+		CreatedTime: timestamppb.New(vm.CreatedTime),
+		Destroyed:   vm.Destroyed,
+	}
+	if vm.DeletionTime != nil {
+		pbVersion.DeletionTime = timestamppb.New(*vm.DeletionTime)
+	}
+	return pbVersion
+}
+
+func metadataToProto(path string, meta *secrets.Metadata) *app.SecretMetadata {
+	versions := make([]int64, 0, len(meta.Versions))
+	for version := range meta.Versions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	pbMeta := &app.SecretMetadata{
+		Path:           path,
+		CreatedTime:    timestamppb.New(meta.CreatedTime),
+		UpdatedTime:    timestamppb.New(meta.UpdatedTime),
+		CurrentVersion: meta.CurrentVersion,
+		OldestVersion:  meta.OldestVersion,
+		MaxVersions:    int32(meta.MaxVersions),
+		CasRequired:    meta.CASRequired,
+	}
+	for _, version := range versions {
+		pbMeta.Versions = append(pbMeta.Versions, versionMetadataToProto(version, meta.Versions[version]))
+	}
+	return pbMeta
+}
+
+// secretError maps storage errors to gRPC status errors
+func secretError(err error) error {
+	switch {
+	case errors.Is(err, secrets.ErrSecretNotFound),
+		errors.Is(err, secrets.ErrVersionNotFound),
+		errors.Is(err, secrets.ErrVersionDeleted):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, secrets.ErrCASMismatch),
+		errors.Is(err, secrets.ErrCASRequired):
+		return casError(err)
+	case errors.Is(err, secrets.ErrInvalidPath),
+		errors.Is(err, secrets.ErrNoVersions),
+		errors.Is(err, keystore.ErrKeyTooLong):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, keystore.ErrBarrierSealed):
+		return status.Error(codes.Unavailable, "vault is sealed")
+	case errors.Is(err, statemanager.ErrSecretLocked):
+		return status.Error(codes.Aborted, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// casError marks a failed check-and-set so the HTTP gateway can answer it
+// with 412, unlike other failed preconditions.
+func casError(err error) error {
+	st := status.New(codes.FailedPrecondition, err.Error())
+	if detailed, derr := st.WithDetails(&errdetails.ErrorInfo{Reason: CAS_FAILED_REASON}); derr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func isCASError(st *status.Status) bool {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Reason == CAS_FAILED_REASON {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSecretError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"not found", secrets.ErrSecretNotFound, codes.NotFound},
+		{"wrapped not found", fmt.Errorf("reading: %w", secrets.ErrSecretNotFound), codes.NotFound},
+		{"missing version", secrets.ErrVersionNotFound, codes.NotFound},
+		{"deleted version", secrets.ErrVersionDeleted, codes.NotFound},
+		{"cas mismatch", secrets.ErrCASMismatch, codes.FailedPrecondition},
+		{"cas required", secrets.ErrCASRequired, codes.FailedPrecondition},
+		{"invalid path", secrets.ErrInvalidPath, codes.InvalidArgument},
+		{"no versions", secrets.ErrNoVersions, codes.InvalidArgument},
+		{"key too long", fmt.Errorf("storing: %w", keystore.ErrKeyTooLong), codes.InvalidArgument},
+		{"sealed", keystore.ErrBarrierSealed, codes.Unavailable},
+		{"path locked", statemanager.ErrSecretLocked, codes.Aborted},
+		{"other", errors.New("disk on fire"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := status.Code(secretError(tt.err)); code != tt.code {
+				t.Fatalf("got %v, want %v", code, tt.code)
+			}
+		})
+	}
+}
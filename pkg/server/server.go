@@ -7,52 +7,111 @@ import (
 	"net"
 	"net/http"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/skriptvalley/keyhouse/config"
 	"github.com/skriptvalley/keyhouse/pkg/keystore"
 	"github.com/skriptvalley/keyhouse/pkg/middleware"
 	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
 	"github.com/skriptvalley/keyhouse/pkg/statemanager"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	PING_RETRIES = 5
 	RETRY_AFTER  = 2
+	// CLIENT_ADDR_METADATA carries the address of http clients from the
+	// gateway to the handlers
+	CLIENT_ADDR_METADATA = "x-keyhouse-client-addr"
 )
 
 type Server struct {
 	grpcServer *grpc.Server
 	httpServer *http.Server
+	store      keystore.BackendKeyStore
+	snapshots  *snapshotScheduler
 	config     *config.Config
 	logger     *zap.Logger
 }
 
-func NewServer(logger *zap.Logger, cfg *config.Config, sm *statemanager.StateManager) *Server {
-	ctx := context.Background()
-
+// OpenKeystore connects to the configured keystore and brings its schema up
+// to date. It is opened before the state manager, which may share it.
+func OpenKeystore(logger *zap.Logger, cfg *config.Config) keystore.BackendKeyStore {
 	beStore, err := keystore.NewKeystore(cfg.StoreType, cfg.StoreCfgPath)
 	if err != nil {
-		logger.Fatal("Failed to initialize keystore", zap.String("method", "NewServer"), zap.Error(err))
+		logger.Fatal("Failed to initialize keystore", zap.String("method", "OpenKeystore"), zap.Error(err))
 	}
 	for i := 0; i < PING_RETRIES; i++ {
 		if err = beStore.Ping(); err == nil {
-			logger.Info("Connected to keystore database", zap.String("method", "NewServer"))
+			logger.Info("Connected to keystore database", zap.String("method", "OpenKeystore"))
 			break
 		}
-		logger.Warn("Failed to connect to keystore database, retrying", zap.String("method", "NewServer"), zap.Error(err))
+		logger.Warn("Failed to connect to keystore database, retrying", zap.String("method", "OpenKeystore"), zap.Error(err))
 		time.Sleep(RETRY_AFTER * time.Second)
 	}
+	if migrator, ok := beStore.(keystore.Migrator); ok {
+		if err = migrator.Migrate(); err != nil {
+			logger.Fatal("Failed to migrate keystore schema", zap.String("method", "OpenKeystore"), zap.Error(err))
+		}
+		logger.Info("Keystore schema is up to date", zap.String("method", "OpenKeystore"))
+	}
+	return beStore
+}
+
+func NewServer(logger *zap.Logger, cfg *config.Config, sm *statemanager.StateManager, beStore keystore.BackendKeyStore) *Server {
+	ctx := context.Background()
+	var err error
+
+	// The cache sits below the barrier so it only holds ciphertext, and is
+	// kept consistent across replicas through the state database
+	var cache keystore.CachedKeyStore
+	physical := beStore
+	if cfg.CacheSize > 0 {
+		cache = keystore.NewCache(beStore, cfg.CacheSize)
+		if inv, ok := sm.DB.(keystore.Invalidator); ok {
+			cache.Watch(inv)
+		}
+		physical = cache
+	}
+
+	// Every value goes through the encryption barrier, which stays sealed
+	// until the keyholders rebuild the master key
+	barrier := keystore.NewBarrier(physical)
+	sm.SetBarrier(barrier)
+
+	// Secret writes are serialized between replicas by a lease per path
+	var invalidate func(storageId, key string)
+	if cache != nil {
+		invalidate = cache.Invalidate
+	}
+	engine := secrets.NewEngine(barrier, cfg.SecretMaxVersions)
+	engine.SetPathLocker(sm, invalidate)
 
 	// Create Services
 	appServer := &AppServer{
 		appVersion: cfg.AppVersion,
 		sm:         sm,
-		be:         beStore,
+		be:         barrier,
+		physical:   physical,
+		cache:      cache,
+		secrets:    engine,
+	}
+
+	var snapshots *snapshotScheduler
+	if cfg.SnapshotSchedule != "" {
+		appServer.snapshotSchedule = cfg.SnapshotSchedule
+		snapshots, err = newSnapshotScheduler(logger, cfg, appServer)
+		if err != nil {
+			logger.Fatal("Failed to create snapshot scheduler", zap.String("method", "NewServer"), zap.Error(err))
+		}
 	}
 
 	// Create gRPC server
@@ -60,6 +119,10 @@ func NewServer(logger *zap.Logger, cfg *config.Config, sm *statemanager.StateMan
 		grpc.ChainUnaryInterceptor(
 			middleware.GRPCLoggingMiddleware(logger),  // Add the logging middleware
 			middleware.GRPCRecoveryMiddleware(logger), // Add the recovery middleware
+			middleware.GRPCStripMetadataMiddleware(CLIENT_ADDR_METADATA),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.GRPCStripMetadataStreamMiddleware(CLIENT_ADDR_METADATA),
 		),
 	}
 	grpcSrv := grpc.NewServer(grpcOpts...)
@@ -67,7 +130,11 @@ func NewServer(logger *zap.Logger, cfg *config.Config, sm *statemanager.StateMan
 	app.RegisterAppServer(grpcSrv, appServer)
 
 	// Create HTTP server
-	mux := runtime.NewServeMux()
+	mux := runtime.NewServeMux(
+		runtime.WithErrorHandler(httpErrorHandler),
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithMetadata(clientAddr),
+	)
 	httpHandler := registerMiddlewares(logger, mux)
 	httpServer := &http.Server{
 		Handler: httpHandler,
@@ -82,6 +149,8 @@ func NewServer(logger *zap.Logger, cfg *config.Config, sm *statemanager.StateMan
 	return &Server{
 		grpcServer: grpcSrv,
 		httpServer: httpServer,
+		store:      barrier,
+		snapshots:  snapshots,
 		config:     cfg,
 		logger:     logger.With(zap.String("component", "server")),
 	}
@@ -114,6 +183,10 @@ func (s *Server) Start() {
 			}
 		}()
 	}
+
+	if s.snapshots != nil {
+		s.snapshots.Start()
+	}
 }
 
 func (s *Server) Shutdown(ctx context.Context) {
@@ -122,6 +195,12 @@ func (s *Server) Shutdown(ctx context.Context) {
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		s.logger.Error("HTTP server shutdown error", zap.Error(err))
 	}
+	if s.snapshots != nil {
+		s.snapshots.Stop()
+	}
+	if err := s.store.Close(); err != nil {
+		s.logger.Error("Keystore close error", zap.Error(err))
+	}
 }
 
 func (s *Server) startSwaggerServer() *http.Server {
@@ -147,6 +226,15 @@ func (s *Server) startSwaggerServer() *http.Server {
 	return swaggerServer
 }
 
+// httpErrorHandler reports failed check-and-set writes as 412. Other failed
+// preconditions keep the gateway's default 400.
+func httpErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition && isCASError(st) {
+		err = &runtime.HTTPStatusError{HTTPStatus: http.StatusPreconditionFailed, Err: err}
+	}
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
 func registerMiddlewares(logger *zap.Logger, mux *runtime.ServeMux) http.Handler {
 	var handler http.Handler = mux
 	handler = middleware.HTTPLoggingMiddleware(logger)(handler)
@@ -154,3 +242,26 @@ func registerMiddlewares(logger *zap.Logger, mux *runtime.ServeMux) http.Handler
 
 	return handler
 }
+
+// clientAddr passes the address of the http client to handlers. Calls from
+// the gateway do not go through the grpc server, so the grpc interceptors
+// strip it from every other call.
+func clientAddr(ctx context.Context, r *http.Request) metadata.MD {
+	return metadata.Pairs(CLIENT_ADDR_METADATA, r.RemoteAddr)
+}
+
+// headerMatcher forwards the root token and init code headers to handlers
+// as metadata, along with the headers the gateway forwards by default.
+func headerMatcher(key string) (string, bool) {
+	// only the gateway itself may set the client address
+	if strings.EqualFold(strings.TrimPrefix(strings.ToLower(key), "grpc-metadata-"), CLIENT_ADDR_METADATA) {
+		return "", false
+	}
+	if strings.EqualFold(key, ROOT_TOKEN_HEADER) {
+		return ROOT_TOKEN_HEADER, true
+	}
+	if strings.EqualFold(key, INIT_CODE_HEADER) {
+		return INIT_CODE_HEADER, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
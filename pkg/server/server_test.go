@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/skriptvalley/keyhouse/pkg/secrets"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"cas mismatch", secretError(secrets.ErrCASMismatch), http.StatusPreconditionFailed},
+		{"cas required", secretError(secrets.ErrCASRequired), http.StatusPreconditionFailed},
+		{"not found", secretError(secrets.ErrSecretNotFound), http.StatusNotFound},
+		{"other failed precondition", status.Error(codes.FailedPrecondition, "vault is not initialized"), http.StatusBadRequest},
+		{"unavailable", status.Error(codes.Unavailable, "vault is sealed"), http.StatusServiceUnavailable},
+	}
+	mux := runtime.NewServeMux()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPut, "/v1/secrets/app/db", nil)
+			httpErrorHandler(context.Background(), mux, &runtime.JSONPb{}, w, r, tt.err)
+			if w.Code != tt.want {
+				t.Fatalf("got %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderMatcher(t *testing.T) {
+	tests := []struct {
+		header string
+		key    string
+		ok     bool
+	}{
+		{"X-Keyhouse-Token", ROOT_TOKEN_HEADER, true},
+		{"x-keyhouse-token", ROOT_TOKEN_HEADER, true},
+		{"X-Keyhouse-Init-Code", INIT_CODE_HEADER, true},
+		{"Grpc-Metadata-Trace", "Trace", true},
+		{"X-Other", "", false},
+		{"X-Keyhouse-Client-Addr", "", false},
+		{"Grpc-Metadata-X-Keyhouse-Client-Addr", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			key, ok := headerMatcher(tt.header)
+			if ok != tt.ok || (ok && key != tt.key) {
+				t.Fatalf("got %q, %v, want %q, %v", key, ok, tt.key, tt.ok)
+			}
+		})
+	}
+}
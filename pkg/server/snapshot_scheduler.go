@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/skriptvalley/keyhouse/config"
+	"github.com/skriptvalley/keyhouse/pkg/snapshot"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	// only the node holding this lease takes scheduled snapshots
+	SNAPSHOT_LEASE       = "snapshot_scheduler"
+	SNAPSHOT_LEASE_TTL   = 30 * time.Second
+	SNAPSHOT_LEASE_RENEW = 10 * time.Second
+)
+
+// snapshotScheduler takes snapshots on a schedule and prunes old ones. Every
+// node runs one, but only the active node, which holds the scheduler lease,
+// takes snapshots, and only while the vault is unsealed.
+type snapshotScheduler struct {
+	app       *AppServer
+	schedule  cron.Schedule
+	dest      snapshot.Destination
+	retention snapshot.Retention
+	logger    *zap.Logger
+
+	active atomic.Bool
+	stop   context.CancelFunc
+	done   chan struct{}
+}
+
+func newSnapshotScheduler(logger *zap.Logger, cfg *config.Config, appServer *AppServer) (*snapshotScheduler, error) {
+	schedule, err := snapshot.ParseSchedule(cfg.SnapshotSchedule)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := snapshot.NewLocalDestination(cfg.SnapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotScheduler{
+		app:      appServer,
+		schedule: schedule,
+		dest:     dest,
+		retention: snapshot.Retention{
+			Count:  cfg.SnapshotRetainCount,
+			MaxAge: cfg.SnapshotRetainAge,
+		},
+		logger: logger.With(zap.String("component", "snapshot_scheduler")),
+	}, nil
+}
+
+func (s *snapshotScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.done = make(chan struct{})
+	go s.holdLease(ctx)
+	go func() {
+		defer close(s.done)
+		s.run(ctx)
+	}()
+	s.logger.Info("snapshot scheduler started", zap.String("destination", s.dest.String()))
+}
+
+// Stop waits for a running snapshot to finish and gives up the lease.
+func (s *snapshotScheduler) Stop() {
+	s.stop()
+	<-s.done
+	if err := s.app.sm.DB.ReleaseLease(context.Background(), SNAPSHOT_LEASE, s.app.sm.NodeID()); err != nil {
+		s.logger.Warn("failed to release snapshot lease", zap.Error(err))
+	}
+}
+
+func (s *snapshotScheduler) run(ctx context.Context) {
+	timer := time.NewTimer(time.Until(s.schedule.Next(time.Now())))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.runOnce(ctx)
+			timer.Reset(time.Until(s.schedule.Next(time.Now())))
+		}
+	}
+}
+
+// holdLease keeps trying to become, or stay, the active node.
+func (s *snapshotScheduler) holdLease(ctx context.Context) {
+	ticker := time.NewTicker(SNAPSHOT_LEASE_RENEW)
+	defer ticker.Stop()
+	for {
+		acquired, err := s.app.sm.DB.AcquireLease(ctx, SNAPSHOT_LEASE, s.app.sm.NodeID(), SNAPSHOT_LEASE_TTL)
+		if err != nil && ctx.Err() == nil {
+			s.logger.Warn("failed to renew snapshot lease", zap.Error(err))
+		}
+		if s.active.Swap(acquired) != acquired && acquired {
+			s.logger.Info("became the active snapshot node")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *snapshotScheduler) runOnce(ctx context.Context) {
+	if !s.active.Load() {
+		s.logger.Debug("skipping snapshot, another node is active")
+		return
+	}
+	state, err := s.app.sm.DB.GetVaultState(ctx)
+	if err != nil || !state.Unsealed() || s.app.sm.Barrier.Sealed() {
+		s.logger.Info("skipping snapshot, vault is sealed")
+		return
+	}
+
+	name := snapshot.Name(time.Now())
+	var count int
+	err = s.dest.Save(name, func(w io.Writer) error {
+		count, err = s.app.writeSnapshot(ctx, w)
+		return err
+	})
+	s.record(ctx, name, err)
+	if err != nil {
+		s.logger.Error("scheduled snapshot failed", zap.Error(err))
+		return
+	}
+	s.logger.Info("scheduled snapshot saved", zap.String("name", name), zap.Int("entries", count))
+	s.prune()
+}
+
+func (s *snapshotScheduler) record(ctx context.Context, name string, snapErr error) {
+	status, err := s.app.sm.DB.GetSnapshotStatus(ctx)
+	if err != nil {
+		s.logger.Warn("failed to read snapshot status", zap.Error(err))
+		status = &statemanager.SnapshotStatus{}
+	}
+	if snapErr != nil {
+		status.LastFailure = time.Now().UTC()
+		status.LastError = snapErr.Error()
+	} else {
+		status.LastSuccess = time.Now().UTC()
+		status.LastSnapshot = name
+	}
+	if err = s.app.sm.DB.SetSnapshotStatus(ctx, status); err != nil {
+		s.logger.Warn("failed to record snapshot status", zap.Error(err))
+	}
+}
+
+func (s *snapshotScheduler) prune() {
+	stored, err := s.dest.List()
+	if err != nil {
+		s.logger.Warn("failed to list snapshots", zap.Error(err))
+		return
+	}
+	for _, expired := range s.retention.Expired(stored, time.Now()) {
+		if err = s.dest.Remove(expired.Name); err != nil {
+			s.logger.Warn("failed to remove expired snapshot", zap.String("name", expired.Name), zap.Error(err))
+			continue
+		}
+		s.logger.Info("removed expired snapshot", zap.String("name", expired.Name))
+	}
+}
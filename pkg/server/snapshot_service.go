@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/snapshot"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SNAPSHOT_CHUNK_SIZE keeps snapshot messages well below the gRPC limit.
+const SNAPSHOT_CHUNK_SIZE = 64 << 10
+
+// SaveSnapshot streams an archive of every keystore entry along with the
+// seal configuration needed to unseal it with the same shares
+func (s *AppServer) SaveSnapshot(req *app.SaveSnapshotRequest, stream grpc.ServerStreamingServer[app.SnapshotChunk]) error {
+	ctx := stream.Context()
+	if err := s.authorizeRoot(ctx); err != nil {
+		return err
+	}
+	out := &chunkWriter{stream: stream}
+	if _, err := s.writeSnapshot(ctx, out); err != nil {
+		return status.Errorf(codes.Internal, "failed to write snapshot: %v", err)
+	}
+	return out.Flush()
+}
+
+// writeSnapshot writes an archive of every keystore entry to out and returns
+// the number of entries written.
+func (s *AppServer) writeSnapshot(ctx context.Context, out io.Writer) (int, error) {
+	sealCfg, keyholders, err := s.sm.ExportSealState(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read seal config: %w", err)
+	}
+	w, err := snapshot.NewWriter(out, &snapshot.Header{
+		CreatedAt: time.Now().UTC(),
+		SealConfig: snapshot.SealConfig{
+			SecretShares:    sealCfg.SecretShares,
+			SecretThreshold: sealCfg.SecretThreshold,
+			KeyDigest:       sealCfg.KeyDigest,
+			RootTokenDigest: sealCfg.RootTokenDigest,
+		},
+		Keyholders: keyholders,
+	})
+	if err != nil {
+		return 0, err
+	}
+	// entries are read below the barrier, so they stay encrypted and the
+	// vault does not need to be unsealed
+	count := 0
+	err = s.secrets.Freeze(func() error {
+		for _, storageId := range keystore.StorageIDs {
+			err := keystore.Walk(s.physical, storageId, "", func(key string) error {
+				value, err := s.physical.Retrieve(storageId, key)
+				if err != nil {
+					return err
+				}
+				count++
+				return w.WriteEntry(storageId, key, value)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, w.Close()
+}
+
+// RestoreSnapshot replaces the keystore and seal configuration with an
+// archive and leaves the vault sealed
+func (s *AppServer) RestoreSnapshot(stream grpc.ClientStreamingServer[app.SnapshotChunk, app.RestoreSnapshotResponse]) error {
+	ctx := withActor(stream.Context(), "root-token")
+	// a fresh keyhouse has no root token yet, and the root token of a
+	// restore that failed to unseal is not trusted, so the init code
+	// authorizes the restore instead
+	unverified, err := s.sm.SealConfigUnverified(ctx)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to read seal config")
+	}
+	var initCode string
+	if unverified || s.sm.IsVaultDown(ctx) {
+		ctx = withActor(stream.Context(), "init-code")
+		if initCode, err = s.authorizeInitCode(ctx); err != nil {
+			return err
+		}
+	} else if err := s.authorizeRoot(ctx); err != nil {
+		return err
+	}
+	maintenance, err := s.sm.IsInMaintenance(ctx)
+	if err != nil {
+		return status.Error(codes.Internal, "failed to check maintenance mode")
+	}
+	if maintenance {
+		return status.Error(codes.Unavailable, "keyhouse is in maintenance mode")
+	}
+
+	// the archive is verified in full before anything is replaced
+	tmp, err := os.CreateTemp("", "keyhouse-restore-*")
+	if err != nil {
+		return status.Error(codes.Internal, "failed to buffer snapshot")
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if _, err = tmp.Write(chunk.GetData()); err != nil {
+			return status.Error(codes.Internal, "failed to buffer snapshot")
+		}
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return status.Error(codes.Internal, "failed to read snapshot")
+	}
+	header, count, err := snapshot.Verify(tmp)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid snapshot: %v", err)
+	}
+	sealCfg := &statemanager.SealConfig{
+		SecretShares:    header.SealConfig.SecretShares,
+		SecretThreshold: header.SealConfig.SecretThreshold,
+		KeyDigest:       header.SealConfig.KeyDigest,
+		RootTokenDigest: header.SealConfig.RootTokenDigest,
+	}
+	if len(header.Keyholders) != sealCfg.SecretShares || sealCfg.SecretThreshold < 1 || sealCfg.SecretThreshold > sealCfg.SecretShares {
+		return status.Error(codes.InvalidArgument, "invalid snapshot: inconsistent seal config")
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return status.Error(codes.Internal, "failed to read snapshot")
+	}
+
+	err = s.secrets.Freeze(func() error {
+		return s.restoreSnapshot(ctx, tmp, sealCfg, header.Keyholders)
+	})
+	if isVaultBusy(err) {
+		return status.Errorf(codes.Aborted, "failed to restore snapshot, the keystore may be partially restored: %v", err)
+	} else if err != nil {
+		return status.Errorf(codes.Internal, "failed to restore snapshot, the keystore may be partially restored: %v", err)
+	}
+	// the code is only used up by a restore that went through, so a
+	// rejected or failed one can be retried with it
+	if initCode != "" {
+		if err = s.sm.ConsumeInitCode(ctx, initCode); errors.Is(err, statemanager.ErrInvalidInitCode) {
+			return status.Error(codes.Aborted, "snapshot restored, but another call used the init code meanwhile. check the vault state before retrying")
+		} else if err != nil {
+			return status.Error(codes.Internal, "snapshot restored, but failed to consume the init code")
+		}
+	}
+	return stream.SendAndClose(&app.RestoreSnapshotResponse{
+		Status:  string(statemanager.VAULT_STATE_SEALED),
+		Message: "snapshot restored. unlock the vault with the unseal keys of the snapshot",
+		Entries: int64(count),
+	})
+}
+
+func (s *AppServer) restoreSnapshot(ctx context.Context, archive io.Reader, sealCfg *statemanager.SealConfig, keyholders []string) error {
+	// nothing may be served from the old keystore from here on
+	if err := s.sm.PrepareRestore(ctx); err != nil {
+		return err
+	}
+
+	// the keystore is replaced in one transaction where the backend
+	// supports it, so a failed restore does not leave it half written
+	var ops []keystore.Operation
+	for _, storageId := range keystore.StorageIDs {
+		err := keystore.Walk(s.physical, storageId, "", func(key string) error {
+			ops = append(ops, keystore.Operation{Type: keystore.DELETE_OPERATION, StorageId: storageId, Key: key})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	r, err := snapshot.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	for {
+		entry, err := r.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		ops = append(ops, keystore.Operation{
+			Type:      keystore.PUT_OPERATION,
+			StorageId: entry.StorageId,
+			Key:       entry.Key,
+			Value:     entry.Value,
+		})
+	}
+	if err = keystore.ApplyOperations(s.physical, ops); err != nil {
+		return err
+	}
+	return s.sm.RestoreSealState(ctx, sealCfg, keyholders)
+}
+
+// chunkWriter sends everything written to it as snapshot chunks.
+type chunkWriter struct {
+	stream grpc.ServerStreamingServer[app.SnapshotChunk]
+	buf    []byte
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	for len(c.buf) >= SNAPSHOT_CHUNK_SIZE {
+		if err := c.stream.Send(&app.SnapshotChunk{Data: c.buf[:SNAPSHOT_CHUNK_SIZE]}); err != nil {
+			return 0, err
+		}
+		c.buf = c.buf[SNAPSHOT_CHUNK_SIZE:]
+	}
+	return len(p), nil
+}
+
+func (c *chunkWriter) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	err := c.stream.Send(&app.SnapshotChunk{Data: c.buf})
+	c.buf = nil
+	return err
+}
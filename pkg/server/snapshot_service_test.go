@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/pb/app"
+	"github.com/skriptvalley/keyhouse/pkg/statemanager"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// restoreStream feeds an archive to RestoreSnapshot in one chunk.
+type restoreStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	archive []byte
+	resp    *app.RestoreSnapshotResponse
+}
+
+func (s *restoreStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *restoreStream) Recv() (*app.SnapshotChunk, error) {
+	if s.archive == nil {
+		return nil, io.EOF
+	}
+	chunk := &app.SnapshotChunk{Data: s.archive}
+	s.archive = nil
+	return chunk, nil
+}
+
+func (s *restoreStream) SendAndClose(resp *app.RestoreSnapshotResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func TestRestoreSnapshotInitCode(t *testing.T) {
+	ctx := context.Background()
+	source := newTestAppServer(t)
+	shares, _, err := source.sm.GenerateKeys(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if _, err = source.sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if _, err = source.secrets.Put("app/db", map[string]string{"password": "hunter2"}, nil); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	var archive bytes.Buffer
+	if _, err = source.writeSnapshot(ctx, &archive); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+
+	target := newTestAppServer(t)
+	initCode, err := target.sm.GetInitCode(ctx)
+	if err != nil {
+		t.Fatalf("GetInitCode: %v", err)
+	}
+	withCode := withHeader(INIT_CODE_HEADER, initCode)
+
+	// a rejected archive leaves the init code for a retry
+	corrupt := append([]byte(nil), archive.Bytes()...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	err = target.RestoreSnapshot(&restoreStream{ctx: withCode, archive: corrupt})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("RestoreSnapshot of a corrupt archive: got %v, want %v", err, codes.InvalidArgument)
+	}
+	if err = target.sm.CheckInitCode(ctx, initCode); err != nil {
+		t.Fatalf("init code was used up by a rejected restore: %v", err)
+	}
+
+	stream := &restoreStream{ctx: withCode, archive: archive.Bytes()}
+	if err = target.RestoreSnapshot(stream); err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	if stream.resp.GetEntries() == 0 {
+		t.Fatalf("no entries restored")
+	}
+	if err = target.sm.CheckInitCode(ctx, initCode); !errors.Is(err, statemanager.ErrInitCodeExpired) {
+		t.Fatalf("CheckInitCode after restore: got %v, want %v", err, statemanager.ErrInitCodeExpired)
+	}
+
+	// the restored keystore unseals with the shares of the source
+	if _, err = target.sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	secret, err := target.secrets.Get("app/db", 0)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if secret.Data["password"] != "hunter2" {
+		t.Fatalf("got %v, want the restored secret", secret.Data)
+	}
+}
+
+func TestRestoreSnapshotReplacesKeystore(t *testing.T) {
+	ctx := context.Background()
+	newServer := func(path string) (*AppServer, []string, string) {
+		s := newTestAppServer(t)
+		shares, rootToken, err := s.sm.GenerateKeys(ctx, 1, 1)
+		if err != nil {
+			t.Fatalf("GenerateKeys: %v", err)
+		}
+		if _, err = s.sm.UnlockVault(ctx, shares[0], ""); err != nil {
+			t.Fatalf("UnlockVault: %v", err)
+		}
+		if _, err = s.secrets.Put(path, map[string]string{"path": path}, nil); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		return s, shares, rootToken
+	}
+	source, shares, _ := newServer("app/db")
+	var archive bytes.Buffer
+	if _, err := source.writeSnapshot(ctx, &archive); err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+
+	target, _, rootToken := newServer("old/db")
+	stream := &restoreStream{ctx: withHeader(ROOT_TOKEN_HEADER, rootToken), archive: archive.Bytes()}
+	if err := target.RestoreSnapshot(stream); err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	if _, err := target.sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if _, err := target.secrets.Get("app/db", 0); err != nil {
+		t.Fatalf("Get of a restored secret: %v", err)
+	}
+	// nothing of the old keystore survives the restore
+	if _, err := target.secrets.Get("old/db", 0); err == nil {
+		t.Fatalf("Get of a secret from before the restore succeeded")
+	}
+}
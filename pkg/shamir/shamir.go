@@ -0,0 +1,173 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Shares produced by Split carry the y values of every secret byte followed
+// by a single trailing byte holding the x coordinate of the share.
+const SHARE_OVERHEAD = 1
+
+var (
+	expTable [255]byte
+	logTable [256]byte
+)
+
+func init() {
+	// 0x03 generates the multiplicative group of GF(2^8) reduced by the
+	// AES polynomial x^8 + x^4 + x^3 + x + 1.
+	var x byte = 1
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		logTable[x] = byte(i)
+		x = x ^ xtime(x)
+	}
+}
+
+func xtime(a byte) byte {
+	if a&0x80 != 0 {
+		return (a << 1) ^ 0x1b
+	}
+	return a << 1
+}
+
+func add(a, b byte) byte {
+	return a ^ b
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])+int(logTable[b]))%255]
+}
+
+func div(a, b byte) byte {
+	if b == 0 {
+		panic("shamir: division by zero")
+	}
+	if a == 0 {
+		return 0
+	}
+	return expTable[(int(logTable[a])-int(logTable[b])+255)%255]
+}
+
+// polynomial is a random polynomial over GF(2^8) whose intercept is the
+// secret byte.
+type polynomial []byte
+
+func newPolynomial(intercept byte, degree int) (polynomial, error) {
+	p := make(polynomial, degree+1)
+	p[0] = intercept
+	if _, err := rand.Read(p[1:]); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p polynomial) evaluate(x byte) byte {
+	// Horner's method
+	out := p[len(p)-1]
+	for i := len(p) - 2; i >= 0; i-- {
+		out = add(mul(out, x), p[i])
+	}
+	return out
+}
+
+// Split divides the secret into parts shares, any threshold of which are
+// enough to rebuild it with Combine.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("cannot split an empty secret")
+	}
+	if parts < 1 || parts > 255 {
+		return nil, fmt.Errorf("parts must be between 1 and 255, got %d", parts)
+	}
+	if threshold < 1 || threshold > parts {
+		return nil, fmt.Errorf("threshold must be between 1 and %d, got %d", parts, threshold)
+	}
+
+	xCoords, err := randomCoordinates(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+SHARE_OVERHEAD)
+		shares[i][len(secret)] = xCoords[i]
+	}
+	for idx, b := range secret {
+		p, err := newPolynomial(b, threshold-1)
+		if err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i][idx] = p.evaluate(xCoords[i])
+		}
+	}
+	return shares, nil
+}
+
+// Combine rebuilds the secret from a set of shares produced by Split. It
+// cannot tell whether enough shares were provided; callers must verify the
+// result.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares provided")
+	}
+	size := len(shares[0])
+	if size <= SHARE_OVERHEAD {
+		return nil, fmt.Errorf("shares are too short")
+	}
+	xCoords := make([]byte, len(shares))
+	seen := make(map[byte]bool, len(shares))
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, fmt.Errorf("all shares must be the same length")
+		}
+		x := share[size-1]
+		if x == 0 || seen[x] {
+			return nil, fmt.Errorf("duplicate or invalid share")
+		}
+		seen[x] = true
+		xCoords[i] = x
+	}
+
+	secret := make([]byte, size-SHARE_OVERHEAD)
+	for idx := range secret {
+		// Lagrange interpolation at x = 0
+		var value byte
+		for i, xi := range xCoords {
+			basis := byte(1)
+			for j, xj := range xCoords {
+				if i == j {
+					continue
+				}
+				basis = mul(basis, div(xj, add(xj, xi)))
+			}
+			value = add(value, mul(shares[i][idx], basis))
+		}
+		secret[idx] = value
+	}
+	return secret, nil
+}
+
+// randomCoordinates returns n distinct non-zero x coordinates.
+func randomCoordinates(n int) ([]byte, error) {
+	coords := make([]byte, 255)
+	for i := range coords {
+		coords[i] = byte(i + 1)
+	}
+	// Fisher-Yates shuffle driven by crypto/rand
+	buf := make([]byte, 2)
+	for i := len(coords) - 1; i > 0; i-- {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		j := (int(buf[0])<<8 | int(buf[1])) % (i + 1)
+		coords[i], coords[j] = coords[j], coords[i]
+	}
+	return coords[:n], nil
+}
@@ -0,0 +1,124 @@
+package shamir
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	tests := []struct {
+		name      string
+		parts     int
+		threshold int
+	}{
+		{"single share", 1, 1},
+		{"threshold of one", 5, 1},
+		{"threshold below parts", 5, 3},
+		{"threshold equal to parts", 5, 5},
+		{"maximum parts", 255, 255},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := Split(secret, tt.parts, tt.threshold)
+			if err != nil {
+				t.Fatalf("Split: %v", err)
+			}
+			if len(shares) != tt.parts {
+				t.Fatalf("got %d shares, want %d", len(shares), tt.parts)
+			}
+			for _, share := range shares {
+				if len(share) != len(secret)+SHARE_OVERHEAD {
+					t.Fatalf("got share of %d bytes, want %d", len(share), len(secret)+SHARE_OVERHEAD)
+				}
+			}
+
+			// any threshold shares rebuild the secret, from either end
+			for _, subset := range [][][]byte{shares[:tt.threshold], shares[tt.parts-tt.threshold:]} {
+				combined, err := Combine(subset)
+				if err != nil {
+					t.Fatalf("Combine: %v", err)
+				}
+				if !bytes.Equal(combined, secret) {
+					t.Fatalf("combined %q, want %q", combined, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestCombineBelowThreshold(t *testing.T) {
+	secret := []byte("correct horse battery staple")
+	tests := []struct {
+		name      string
+		parts     int
+		threshold int
+	}{
+		{"two of three", 3, 2},
+		{"three of five", 5, 3},
+		{"five of five", 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares, err := Split(secret, tt.parts, tt.threshold)
+			if err != nil {
+				t.Fatalf("Split: %v", err)
+			}
+			combined, err := Combine(shares[:tt.threshold-1])
+			if err != nil {
+				t.Fatalf("Combine: %v", err)
+			}
+			if bytes.Equal(combined, secret) {
+				t.Fatalf("threshold-1 shares rebuilt the secret")
+			}
+		})
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    []byte
+		parts     int
+		threshold int
+	}{
+		{"empty secret", nil, 3, 2},
+		{"no parts", []byte("secret"), 0, 1},
+		{"too many parts", []byte("secret"), 256, 2},
+		{"no threshold", []byte("secret"), 3, 0},
+		{"threshold above parts", []byte("secret"), 3, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Split(tt.secret, tt.parts, tt.threshold); err == nil {
+				t.Fatalf("Split(%d, %d) succeeded", tt.parts, tt.threshold)
+			}
+		})
+	}
+}
+
+func TestCombineInvalid(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	zeroX := append([]byte(nil), shares[0]...)
+	zeroX[len(zeroX)-1] = 0
+	tests := []struct {
+		name   string
+		shares [][]byte
+	}{
+		{"no shares", nil},
+		{"share too short", [][]byte{{1}}},
+		{"mismatched lengths", [][]byte{shares[0], shares[1][1:]}},
+		{"duplicate share", [][]byte{shares[0], shares[0]}},
+		{"zero coordinate", [][]byte{zeroX, shares[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Combine(tt.shares); err == nil {
+				t.Fatalf("Combine succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FILE_PREFIX = "keyhouse-"
+	FILE_SUFFIX = ".snap"
+	// TIME_FORMAT is embedded in snapshot names so they sort by age
+	TIME_FORMAT = "20060102T150405Z"
+)
+
+// Stored describes a snapshot kept by a Destination.
+type Stored struct {
+	Name      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Destination is where scheduled snapshots are kept.
+type Destination interface {
+	// Save stores the archive produced by write under name. A failed write
+	// must not leave a partial snapshot behind.
+	Save(name string, write func(w io.Writer) error) error
+	List() ([]Stored, error)
+	Remove(name string) error
+	String() string
+}
+
+// Name returns the name of a snapshot taken at t.
+func Name(t time.Time) string {
+	return FILE_PREFIX + t.UTC().Format(TIME_FORMAT) + FILE_SUFFIX
+}
+
+// LocalDestination keeps snapshots in a directory of the local filesystem.
+type LocalDestination struct {
+	dir string
+}
+
+func NewLocalDestination(dir string) (*LocalDestination, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("snapshot directory is required")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	return &LocalDestination{dir: dir}, nil
+}
+
+func (d *LocalDestination) Save(name string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	if err = tmp.Chmod(0600); err != nil {
+		return err
+	}
+	if err = write(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(d.dir, name))
+}
+
+func (d *LocalDestination) List() ([]Stored, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	var stored []Stored
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, FILE_PREFIX) || !strings.HasSuffix(name, FILE_SUFFIX) {
+			continue
+		}
+		createdAt, err := time.Parse(TIME_FORMAT, strings.TrimSuffix(strings.TrimPrefix(name, FILE_PREFIX), FILE_SUFFIX))
+		if err != nil {
+			// not one of ours
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, Stored{Name: name, CreatedAt: createdAt, Size: info.Size()})
+	}
+	return stored, nil
+}
+
+func (d *LocalDestination) Remove(name string) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return os.Remove(filepath.Join(d.dir, name))
+}
+
+func (d *LocalDestination) String() string {
+	return "local:" + d.dir
+}
+
+// Retention decides which snapshots to prune. Zero values disable a limit.
+// The newest snapshot is always kept.
+type Retention struct {
+	Count  int
+	MaxAge time.Duration
+}
+
+// Expired returns the snapshots that fall outside the retention policy.
+func (r Retention) Expired(stored []Stored, now time.Time) []Stored {
+	sorted := append([]Stored(nil), stored...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	var expired []Stored
+	for i, s := range sorted {
+		if i == 0 {
+			continue
+		}
+		if (r.Count > 0 && i >= r.Count) || (r.MaxAge > 0 && now.Sub(s.CreatedAt) > r.MaxAge) {
+			expired = append(expired, s)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	stored := make([]Stored, 0, 5)
+	// one snapshot a day, listed oldest first
+	for day := 5; day >= 1; day-- {
+		createdAt := now.Add(-time.Duration(day) * 24 * time.Hour)
+		stored = append(stored, Stored{Name: Name(createdAt), CreatedAt: createdAt})
+	}
+	names := func(stored []Stored) []string {
+		var names []string
+		for _, s := range stored {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{"no limits", Retention{}, nil},
+		{"count", Retention{Count: 3}, names([]Stored{stored[1], stored[0]})},
+		{"max age", Retention{MaxAge: 72 * time.Hour}, names([]Stored{stored[1], stored[0]})},
+		{"both limits", Retention{Count: 4, MaxAge: 72 * time.Hour}, names([]Stored{stored[1], stored[0]})},
+		{"newest is always kept", Retention{MaxAge: time.Hour}, names([]Stored{stored[3], stored[2], stored[1], stored[0]})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(tt.retention.Expired(stored, now)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalDestination(t *testing.T) {
+	dir := t.TempDir()
+	dest, err := NewLocalDestination(dir)
+	if err != nil {
+		t.Fatalf("NewLocalDestination: %v", err)
+	}
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	name := Name(createdAt)
+	err = dest.Save(name, func(w io.Writer) error {
+		_, err := w.Write([]byte("archive"))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// a failed write leaves nothing behind
+	errWrite := errors.New("write failed")
+	err = dest.Save(Name(createdAt.Add(time.Hour)), func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("failed Save: got %v, want %v", err, errWrite)
+	}
+	// files that are not snapshots are ignored
+	if err = os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	stored, err := dest.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []Stored{{Name: name, CreatedAt: createdAt, Size: int64(len("archive"))}}
+	if !reflect.DeepEqual(stored, want) {
+		t.Fatalf("got %+v, want %+v", stored, want)
+	}
+
+	if err = dest.Remove("../" + name); err == nil {
+		t.Fatalf("Remove accepted a path outside the directory")
+	}
+	if err = dest.Remove(name); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if stored, _ = dest.List(); len(stored) != 0 {
+		t.Fatalf("got %+v after Remove", stored)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+		err  bool
+	}{
+		{"6h", from.Add(6 * time.Hour), false},
+		{"@daily", time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), false},
+		{"30 2 * * *", time.Date(2024, 1, 3, 2, 30, 0, 0, time.UTC), false},
+		{"10s", time.Time{}, true},
+		{"sometimes", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if (err != nil) != tt.err {
+				t.Fatalf("got %v, want error %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if next := schedule.Next(from); !next.Equal(tt.next) {
+				t.Fatalf("next run: got %v, want %v", next, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ParseSchedule accepts either a Go duration such as "6h" or a standard
+// five-field cron expression, including descriptors like "@daily".
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval < time.Minute {
+			return nil, fmt.Errorf("snapshot interval must be at least a minute")
+		}
+		return cron.Every(interval), nil
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot schedule %q: not a duration or cron expression", spec)
+	}
+	return schedule, nil
+}
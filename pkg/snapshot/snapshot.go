@@ -0,0 +1,249 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// An archive is laid out as
+//
+//	magic | version | header | entry... | end marker | sha256
+//
+// where the header and every field of an entry are length-prefixed, and the
+// trailing checksum covers every byte before it. Entry values are copied as
+// stored, so they stay encrypted by the barrier.
+//
+// The checksum only detects corruption. The header is authenticated by the
+// keyring in the archive, which is sealed by the master key together with a
+// digest of the seal config, so a restored header is only trusted once an
+// unseal has matched it.
+const (
+	MAGIC   = "KHSNAP"
+	VERSION = byte(1)
+
+	RECORD_END   = byte(0)
+	RECORD_ENTRY = byte(1)
+
+	// MAX_FIELD_SIZE bounds a single length-prefixed field so a corrupt
+	// archive cannot make the reader allocate without limit.
+	MAX_FIELD_SIZE = 64 << 20
+)
+
+var (
+	ErrInvalidArchive   = errors.New("not a keyhouse snapshot")
+	ErrChecksumMismatch = errors.New("snapshot checksum mismatch")
+)
+
+// SealConfig mirrors the seal configuration of the vault the snapshot was
+// taken from.
+type SealConfig struct {
+	SecretShares    int    `json:"secret_shares"`
+	SecretThreshold int    `json:"secret_threshold"`
+	KeyDigest       string `json:"key_digest"`
+	RootTokenDigest string `json:"root_token_digest"`
+}
+
+type Header struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	SealConfig SealConfig `json:"seal_config"`
+	Keyholders []string   `json:"keyholders"`
+}
+
+type Entry struct {
+	StorageId string
+	Key       string
+	Value     []byte
+}
+
+// Writer writes an archive. Close must be called to append the checksum.
+type Writer struct {
+	w   *bufio.Writer
+	sum hash.Hash
+	out io.Writer
+}
+
+func NewWriter(w io.Writer, header *Header) (*Writer, error) {
+	sw := &Writer{w: bufio.NewWriter(w), sum: sha256.New()}
+	sw.out = io.MultiWriter(sw.w, sw.sum)
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot header: %w", err)
+	}
+	if _, err = sw.out.Write(append([]byte(MAGIC), VERSION)); err != nil {
+		return nil, err
+	}
+	if err = sw.writeField(encoded); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *Writer) WriteEntry(storageId, key string, value []byte) error {
+	if _, err := sw.out.Write([]byte{RECORD_ENTRY}); err != nil {
+		return err
+	}
+	for _, field := range [][]byte{[]byte(storageId), []byte(key), value} {
+		if err := sw.writeField(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the end marker and checksum and flushes the archive. It does
+// not close the underlying writer.
+func (sw *Writer) Close() error {
+	if _, err := sw.out.Write([]byte{RECORD_END}); err != nil {
+		return err
+	}
+	if _, err := sw.w.Write(sw.sum.Sum(nil)); err != nil {
+		return err
+	}
+	return sw.w.Flush()
+}
+
+func (sw *Writer) writeField(b []byte) error {
+	if len(b) > MAX_FIELD_SIZE {
+		return fmt.Errorf("snapshot field of %d bytes is too large", len(b))
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(b)))
+	if _, err := sw.out.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := sw.out.Write(b)
+	return err
+}
+
+// Reader reads an archive. Next only reports the end of the archive once the
+// checksum has been verified, so a caller applying entries as it reads must
+// be prepared to roll back; use Verify first to avoid that.
+type Reader struct {
+	r      *bufio.Reader
+	sum    hash.Hash
+	in     io.Reader
+	header *Header
+	done   bool
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	sr := &Reader{r: bufio.NewReader(r), sum: sha256.New()}
+	sr.in = io.TeeReader(sr.r, sr.sum)
+
+	prefix := make([]byte, len(MAGIC)+1)
+	if _, err := io.ReadFull(sr.in, prefix); err != nil {
+		return nil, ErrInvalidArchive
+	}
+	if !bytes.Equal(prefix[:len(MAGIC)], []byte(MAGIC)) {
+		return nil, ErrInvalidArchive
+	}
+	if prefix[len(MAGIC)] != VERSION {
+		return nil, fmt.Errorf("unsupported snapshot version %d", prefix[len(MAGIC)])
+	}
+	encoded, err := sr.readField()
+	if err != nil {
+		return nil, err
+	}
+	var header Header
+	if err = json.Unmarshal(encoded, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot header: %w", err)
+	}
+	sr.header = &header
+	return sr, nil
+}
+
+func (sr *Reader) Header() *Header {
+	return sr.header
+}
+
+// Next returns the next entry, or io.EOF after the last entry once the
+// checksum matches.
+func (sr *Reader) Next() (*Entry, error) {
+	if sr.done {
+		return nil, io.EOF
+	}
+	var record [1]byte
+	if _, err := io.ReadFull(sr.in, record[:]); err != nil {
+		return nil, truncated(err)
+	}
+	switch record[0] {
+	case RECORD_ENTRY:
+	case RECORD_END:
+		expected := sr.sum.Sum(nil)
+		actual := make([]byte, sha256.Size)
+		if _, err := io.ReadFull(sr.r, actual); err != nil {
+			return nil, truncated(err)
+		}
+		if subtle.ConstantTimeCompare(expected, actual) != 1 {
+			return nil, ErrChecksumMismatch
+		}
+		if _, err := sr.r.ReadByte(); err != io.EOF {
+			return nil, fmt.Errorf("unexpected data after snapshot checksum")
+		}
+		sr.done = true
+		return nil, io.EOF
+	default:
+		return nil, fmt.Errorf("unknown snapshot record type %d", record[0])
+	}
+
+	var fields [3][]byte
+	for i := range fields {
+		field, err := sr.readField()
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = field
+	}
+	return &Entry{StorageId: string(fields[0]), Key: string(fields[1]), Value: fields[2]}, nil
+}
+
+func (sr *Reader) readField() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(sr.in, size[:]); err != nil {
+		return nil, truncated(err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > MAX_FIELD_SIZE {
+		return nil, fmt.Errorf("snapshot field of %d bytes is too large", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(sr.in, b); err != nil {
+		return nil, truncated(err)
+	}
+	return b, nil
+}
+
+// Verify reads a whole archive and checks its checksum, returning its header
+// and number of entries.
+func Verify(r io.Reader) (*Header, int, error) {
+	sr, err := NewReader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	count := 0
+	for {
+		_, err = sr.Next()
+		if err == io.EOF {
+			return sr.Header(), count, nil
+		} else if err != nil {
+			return nil, 0, err
+		}
+		count++
+	}
+}
+
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("snapshot is truncated")
+	}
+	return err
+}
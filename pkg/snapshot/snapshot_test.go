@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEntries = []Entry{
+	{StorageId: "system", Key: "keyring", Value: []byte("sealed keyring")},
+	{StorageId: "secrets", Key: "app/db", Value: []byte("hunter2")},
+	{StorageId: "secrets", Key: "app/empty", Value: []byte{}},
+}
+
+func testArchive(t *testing.T) ([]byte, *Header) {
+	t.Helper()
+	header := &Header{
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		SealConfig: SealConfig{
+			SecretShares:    3,
+			SecretThreshold: 2,
+			KeyDigest:       "key-digest",
+			RootTokenDigest: "root-token-digest",
+		},
+		Keyholders: []string{"alice", "bob", "carol"},
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, header)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	for _, entry := range testEntries {
+		if err = w.WriteEntry(entry.StorageId, entry.Key, entry.Value); err != nil {
+			t.Fatalf("WriteEntry: %v", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes(), header
+}
+
+func TestRoundTrip(t *testing.T) {
+	archive, header := testArchive(t)
+	r, err := NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	if !reflect.DeepEqual(r.Header(), header) {
+		t.Fatalf("header: got %+v, want %+v", r.Header(), header)
+	}
+	for _, want := range testEntries {
+		entry, err := r.Next()
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if entry.StorageId != want.StorageId || entry.Key != want.Key || !bytes.Equal(entry.Value, want.Value) {
+			t.Fatalf("entry: got %+v, want %+v", entry, want)
+		}
+	}
+	if _, err = r.Next(); err != io.EOF {
+		t.Fatalf("Next after the last entry: got %v, want io.EOF", err)
+	}
+
+	_, count, err := Verify(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if count != len(testEntries) {
+		t.Fatalf("Verify: got %d entries, want %d", count, len(testEntries))
+	}
+}
+
+func TestVerifyDetectsDamage(t *testing.T) {
+	archive, _ := testArchive(t)
+	valueAt := bytes.Index(archive, []byte("hunter2"))
+	if valueAt < 0 {
+		t.Fatalf("entry value not found in archive")
+	}
+	checksumAt := len(archive) - 32
+
+	tests := []struct {
+		name   string
+		mutate func([]byte) []byte
+		err    error
+		msg    string
+	}{
+		{"empty", func(b []byte) []byte { return nil }, ErrInvalidArchive, ""},
+		{"wrong magic", func(b []byte) []byte { b[0] = 'X'; return b }, ErrInvalidArchive, ""},
+		{"unsupported version", func(b []byte) []byte { b[len(MAGIC)]++; return b }, nil, "unsupported snapshot version"},
+		{"tampered value", func(b []byte) []byte { b[valueAt] ^= 0xff; return b }, ErrChecksumMismatch, ""},
+		{"tampered checksum", func(b []byte) []byte { b[checksumAt] ^= 0xff; return b }, ErrChecksumMismatch, ""},
+		{"truncated header", func(b []byte) []byte { return b[:len(MAGIC)+3] }, nil, "truncated"},
+		{"truncated entry", func(b []byte) []byte { return b[:valueAt+2] }, nil, "truncated"},
+		{"missing end marker", func(b []byte) []byte { return b[:checksumAt-1] }, nil, "truncated"},
+		{"truncated checksum", func(b []byte) []byte { return b[:len(b)-1] }, nil, "truncated"},
+		{"trailing data", func(b []byte) []byte { return append(b, 0) }, nil, "unexpected data"},
+		{"unknown record", func(b []byte) []byte { b[checksumAt-1] = 7; return b }, nil, "unknown snapshot record"},
+		{"oversized field", func(b []byte) []byte {
+			copy(b[len(MAGIC)+1:], []byte{0xff, 0xff, 0xff, 0xff})
+			return b
+		}, nil, "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			damaged := tt.mutate(append([]byte(nil), archive...))
+			_, _, err := Verify(bytes.NewReader(damaged))
+			if err == nil {
+				t.Fatalf("Verify accepted a damaged archive")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if tt.msg != "" && !strings.Contains(err.Error(), tt.msg) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.msg)
+			}
+		})
+	}
+}
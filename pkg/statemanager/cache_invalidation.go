@@ -0,0 +1,124 @@
+package statemanager
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	redis "github.com/go-redis/redis/v8"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	CACHE_INVALIDATION_CHANNEL = "keyhouse:cache_invalidation"
+	// postgres channel names are identifiers, so they cannot contain ":"
+	PG_CACHE_INVALIDATION_CHANNEL = "keyhouse_cache_invalidation"
+	RESUBSCRIBE_AFTER             = time.Second
+	MAX_RESUBSCRIBE_AFTER         = time.Minute
+)
+
+type cacheInvalidation struct {
+	StorageId string `json:"storage_id"`
+	Key       string `json:"key"`
+}
+
+// PublishInvalidation tells every replica to drop a cached keystore entry.
+func (rdb *RedisDB) PublishInvalidation(storageId, key string) {
+	msg, err := json.Marshal(&cacheInvalidation{StorageId: storageId, Key: key})
+	if err != nil {
+		rdb.logger.Error("failed to encode cache invalidation", zap.Error(err))
+		return
+	}
+	if err = rdb.client.Publish(context.Background(), CACHE_INVALIDATION_CHANNEL, msg).Err(); err != nil {
+		rdb.logger.Error("failed to publish cache invalidation", zap.String("storage_id", storageId), zap.Error(err))
+	}
+}
+
+// SubscribeInvalidations applies the invalidations published by every
+// replica until ctx is done.
+func (rdb *RedisDB) SubscribeInvalidations(ctx context.Context, invalidate func(storageId, key string), purge func()) {
+	pubsub := rdb.client.Subscribe(ctx, CACHE_INVALIDATION_CHANNEL)
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			// invalidations sent while we were disconnected are lost
+			if msg.Kind == "subscribe" {
+				rdb.logger.Debug("subscribed to cache invalidations")
+				purge()
+			}
+		case *redis.Message:
+			var inv cacheInvalidation
+			if err = json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				rdb.logger.Warn("dropping malformed cache invalidation", zap.Error(err))
+				purge()
+				continue
+			}
+			invalidate(inv.StorageId, inv.Key)
+		}
+		if err != nil {
+			rdb.logger.Warn("cache invalidation subscription failed, retrying", zap.Error(err))
+			purge()
+			time.Sleep(RESUBSCRIBE_AFTER)
+		}
+	}
+}
+
+// PublishInvalidation tells every replica to drop a cached keystore entry.
+func (pdb *PostgresDB) PublishInvalidation(storageId, key string) {
+	msg, err := json.Marshal(&cacheInvalidation{StorageId: storageId, Key: key})
+	if err != nil {
+		pdb.logger.Error("failed to encode cache invalidation", zap.Error(err))
+		return
+	}
+	if _, err = pdb.db.Exec("SELECT pg_notify($1, $2)", PG_CACHE_INVALIDATION_CHANNEL, string(msg)); err != nil {
+		pdb.logger.Error("failed to publish cache invalidation", zap.String("storage_id", storageId), zap.Error(err))
+	}
+}
+
+// SubscribeInvalidations applies the invalidations published by every
+// replica until ctx is done. LISTEN needs a connection of its own, which the
+// listener reopens whenever it drops.
+func (pdb *PostgresDB) SubscribeInvalidations(ctx context.Context, invalidate func(storageId, key string), purge func()) {
+	listener := pq.NewListener(pdb.connStr, RESUBSCRIBE_AFTER, MAX_RESUBSCRIBE_AFTER, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			pdb.logger.Warn("cache invalidation subscription failed, retrying", zap.Error(err))
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(PG_CACHE_INVALIDATION_CHANNEL); err != nil {
+		pdb.logger.Error("failed to subscribe to cache invalidations", zap.Error(err))
+	}
+	purge()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			// a nil notification follows a reconnect, and invalidations sent
+			// while we were disconnected are lost
+			if n == nil {
+				pdb.logger.Debug("resubscribed to cache invalidations")
+				purge()
+				continue
+			}
+			var inv cacheInvalidation
+			if err := json.Unmarshal([]byte(n.Extra), &inv); err != nil {
+				pdb.logger.Warn("dropping malformed cache invalidation", zap.Error(err))
+				purge()
+				continue
+			}
+			invalidate(inv.StorageId, inv.Key)
+		}
+	}
+}
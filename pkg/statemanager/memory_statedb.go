@@ -0,0 +1,375 @@
+package statemanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MemoryDB keeps the vault state in process memory. Missing values are
+// reported as ErrNotFound.
+type MemoryDB struct {
+	l        sync.Mutex
+	state    VaultState
+	history  []*StateTransition
+	initCode string
+	// zero when the init code does not expire
+	initCodeExpire     time.Time
+	initFailures       int
+	initFailuresExpire time.Time
+	keyholders         map[string]bool
+	unsealNonce        string
+	sealConfig         *SealConfig
+	nodes              map[string]time.Time
+	leases             map[string]memoryLease
+	locks              map[string]memoryLock
+	lockFences         map[string]int64
+	snapshot           SnapshotStatus
+	logger             *zap.Logger
+}
+
+type memoryLease struct {
+	holder  string
+	expires time.Time
+}
+
+type memoryLock struct {
+	token   int64
+	expires time.Time
+}
+
+func NewMemoryDB(logger *zap.Logger) *MemoryDB {
+	return &MemoryDB{
+		keyholders: make(map[string]bool),
+		nodes:      make(map[string]time.Time),
+		leases:     make(map[string]memoryLease),
+		locks:      make(map[string]memoryLock),
+		lockFences: make(map[string]int64),
+		logger:     logger.With(zap.String("component", "memorydb")),
+	}
+}
+
+func (mdb *MemoryDB) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (mdb *MemoryDB) GetVaultState(ctx context.Context) (VaultState, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.state == "" {
+		return "", ErrNotFound
+	}
+	return mdb.state, nil
+}
+
+func (mdb *MemoryDB) SetVaultState(ctx context.Context, t *StateTransition, fence int64) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if !mdb.fenced(fence) {
+		return ErrVaultLockLost
+	}
+	mdb.state = t.To
+	entry := *t
+	mdb.history = append(mdb.history, &entry)
+	if len(mdb.history) > STATE_HISTORY_MAX {
+		mdb.history = mdb.history[len(mdb.history)-STATE_HISTORY_MAX:]
+	}
+	return nil
+}
+
+func (mdb *MemoryDB) GetStateHistory(ctx context.Context, limit int) ([]*StateTransition, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	history := make([]*StateTransition, 0, limit)
+	for i := len(mdb.history) - 1; i >= 0 && len(history) < limit; i-- {
+		entry := *mdb.history[i]
+		history = append(history, &entry)
+	}
+	return history, nil
+}
+
+func (mdb *MemoryDB) CreateOrGetInitCode(ctx context.Context, ttl time.Duration) (string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.currentInitCode() == "" {
+		mdb.setInitCode(uuid.New().String(), ttl)
+	}
+	return mdb.initCode, nil
+}
+
+func (mdb *MemoryDB) GetInitCode(ctx context.Context) (string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.currentInitCode() == "" {
+		return "", ErrNotFound
+	}
+	return mdb.initCode, nil
+}
+
+func (mdb *MemoryDB) ConsumeInitCode(ctx context.Context, code string) (bool, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.currentInitCode() != code {
+		return false, nil
+	}
+	mdb.initCode = ""
+	return true, nil
+}
+
+func (mdb *MemoryDB) RotateInitCode(ctx context.Context, ttl time.Duration) (string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	mdb.setInitCode(uuid.New().String(), ttl)
+	return mdb.initCode, nil
+}
+
+func (mdb *MemoryDB) IncrInitFailures(ctx context.Context, window time.Duration) (int, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if time.Now().After(mdb.initFailuresExpire) {
+		mdb.initFailures = 0
+		mdb.initFailuresExpire = time.Now().Add(window)
+	}
+	mdb.initFailures++
+	return mdb.initFailures, nil
+}
+
+func (mdb *MemoryDB) GetInitFailures(ctx context.Context) (int, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if time.Now().After(mdb.initFailuresExpire) {
+		return 0, nil
+	}
+	return mdb.initFailures, nil
+}
+
+func (mdb *MemoryDB) ResetInitFailures(ctx context.Context) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	mdb.initFailures = 0
+	return nil
+}
+
+// currentInitCode returns the init code, or "" once it has expired. It must
+// be called with mdb.l held.
+func (mdb *MemoryDB) currentInitCode() string {
+	if !mdb.initCodeExpire.IsZero() && time.Now().After(mdb.initCodeExpire) {
+		mdb.initCode = ""
+	}
+	return mdb.initCode
+}
+
+func (mdb *MemoryDB) setInitCode(code string, ttl time.Duration) {
+	mdb.initCode = code
+	mdb.initCodeExpire = time.Time{}
+	if ttl > 0 {
+		mdb.initCodeExpire = time.Now().Add(ttl)
+	}
+}
+
+func (mdb *MemoryDB) SetKeyholders(ctx context.Context, cfg *SealConfig, keyholders []string, fence int64) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if !mdb.fenced(fence) {
+		return ErrVaultLockLost
+	}
+	mdb.keyholders = make(map[string]bool, len(keyholders))
+	for _, keyholder := range keyholders {
+		mdb.keyholders[KEY_PREFIX+":"+keyholder] = false
+	}
+	sealCfg := *cfg
+	mdb.sealConfig = &sealCfg
+	return nil
+}
+
+func (mdb *MemoryDB) GetKeyholders(ctx context.Context) (map[string]bool, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.sealConfig == nil {
+		return nil, ErrNotFound
+	}
+	if len(mdb.keyholders) != mdb.sealConfig.SecretShares {
+		return nil, fmt.Errorf("invalid number of keyholders: %d", len(mdb.keyholders))
+	}
+	keyholders := make(map[string]bool, len(mdb.keyholders))
+	for keyholder, active := range mdb.keyholders {
+		keyholders[keyholder] = active
+	}
+	return keyholders, nil
+}
+
+func (mdb *MemoryDB) GetActiveKeysCount(ctx context.Context) (int, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	return mdb.activeKeysCount(), nil
+}
+
+func (mdb *MemoryDB) ActivateKey(ctx context.Context, keyholder string) (int, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if _, ok := mdb.keyholders[keyholder]; !ok {
+		return 0, ErrNotFound
+	}
+	mdb.keyholders[keyholder] = true
+	return mdb.activeKeysCount(), nil
+}
+
+// activeKeysCount must be called with mdb.l held.
+func (mdb *MemoryDB) activeKeysCount() int {
+	count := 0
+	for _, active := range mdb.keyholders {
+		if active {
+			count++
+		}
+	}
+	return count
+}
+
+func (mdb *MemoryDB) CreateOrGetUnsealNonce(ctx context.Context) (string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.unsealNonce == "" {
+		mdb.unsealNonce = uuid.New().String()
+	}
+	return mdb.unsealNonce, nil
+}
+
+func (mdb *MemoryDB) GetUnsealNonce(ctx context.Context) (string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	return mdb.unsealNonce, nil
+}
+
+func (mdb *MemoryDB) ClearUnsealNonce(ctx context.Context) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	mdb.unsealNonce = ""
+	return nil
+}
+
+func (mdb *MemoryDB) DeactivateKeys(ctx context.Context) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	for keyholder := range mdb.keyholders {
+		mdb.keyholders[keyholder] = false
+	}
+	return nil
+}
+
+func (mdb *MemoryDB) GetSealConfig(ctx context.Context) (*SealConfig, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if mdb.sealConfig == nil {
+		return nil, ErrNotFound
+	}
+	sealCfg := *mdb.sealConfig
+	return &sealCfg, nil
+}
+
+func (mdb *MemoryDB) Heartbeat(ctx context.Context, node string, at time.Time) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	mdb.nodes[node] = at
+	return nil
+}
+
+func (mdb *MemoryDB) RemoveNode(ctx context.Context, node string) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	delete(mdb.nodes, node)
+	return nil
+}
+
+func (mdb *MemoryDB) GetActiveNodes(ctx context.Context, since time.Time) ([]string, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	nodes := make([]string, 0, len(mdb.nodes))
+	for node, at := range mdb.nodes {
+		if at.Before(since) {
+			delete(mdb.nodes, node)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+func (mdb *MemoryDB) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	now := time.Now()
+	lease, ok := mdb.leases[name]
+	if ok && lease.holder != holder && now.Before(lease.expires) {
+		return false, nil
+	}
+	mdb.leases[name] = memoryLease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (mdb *MemoryDB) ReleaseLease(ctx context.Context, name, holder string) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if lease, ok := mdb.leases[name]; ok && lease.holder == holder {
+		delete(mdb.leases, name)
+	}
+	return nil
+}
+
+func (mdb *MemoryDB) AcquireLock(ctx context.Context, name string, ttl time.Duration) (int64, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	now := time.Now()
+	if lock, ok := mdb.locks[name]; ok && now.Before(lock.expires) {
+		return 0, nil
+	}
+	mdb.lockFences[name]++
+	token := mdb.lockFences[name]
+	mdb.locks[name] = memoryLock{token: token, expires: now.Add(ttl)}
+	return token, nil
+}
+
+func (mdb *MemoryDB) CheckLock(ctx context.Context, name string, token int64) (bool, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	lock, ok := mdb.locks[name]
+	return ok && lock.token == token && time.Now().Before(lock.expires), nil
+}
+
+// fenced reports whether fence still holds VAULT_LOCK. It must be called
+// with mdb.l held.
+func (mdb *MemoryDB) fenced(fence int64) bool {
+	if fence == 0 {
+		return true
+	}
+	lock, ok := mdb.locks[VAULT_LOCK]
+	return ok && lock.token == fence && time.Now().Before(lock.expires)
+}
+
+func (mdb *MemoryDB) ReleaseLock(ctx context.Context, name string, token int64) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	if lock, ok := mdb.locks[name]; ok && lock.token == token {
+		delete(mdb.locks, name)
+	}
+	return nil
+}
+
+func (mdb *MemoryDB) SetSnapshotStatus(ctx context.Context, status *SnapshotStatus) error {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	mdb.snapshot = *status
+	return nil
+}
+
+func (mdb *MemoryDB) GetSnapshotStatus(ctx context.Context) (*SnapshotStatus, error) {
+	mdb.l.Lock()
+	defer mdb.l.Unlock()
+	status := mdb.snapshot
+	return &status, nil
+}
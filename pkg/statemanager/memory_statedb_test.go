@@ -0,0 +1,339 @@
+package statemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestMemoryDBMissingValues(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"vault state", func() error {
+			_, err := mdb.GetVaultState(ctx)
+			return err
+		}},
+		{"init code", func() error {
+			_, err := mdb.GetInitCode(ctx)
+			return err
+		}},
+		{"keyholders", func() error {
+			_, err := mdb.GetKeyholders(ctx)
+			return err
+		}},
+		{"seal config", func() error {
+			_, err := mdb.GetSealConfig(ctx)
+			return err
+		}},
+		{"unknown keyholder", func() error {
+			_, err := mdb.ActivateKey(ctx, KEY_PREFIX+":nobody")
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMemoryDBFencedWrites(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		fence func(mdb *MemoryDB) int64
+		err   error
+	}{
+		{"unfenced", func(mdb *MemoryDB) int64 { return 0 }, nil},
+		{"held lock", func(mdb *MemoryDB) int64 {
+			token, _ := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+			return token
+		}, nil},
+		{"released lock", func(mdb *MemoryDB) int64 {
+			token, _ := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+			_ = mdb.ReleaseLock(ctx, VAULT_LOCK, token)
+			return token
+		}, ErrVaultLockLost},
+		{"expired lock", func(mdb *MemoryDB) int64 {
+			token, _ := mdb.AcquireLock(ctx, VAULT_LOCK, time.Nanosecond)
+			time.Sleep(time.Millisecond)
+			return token
+		}, ErrVaultLockLost},
+		{"lock taken over", func(mdb *MemoryDB) int64 {
+			token, _ := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+			_ = mdb.ReleaseLock(ctx, VAULT_LOCK, token)
+			_, _ = mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+			return token
+		}, ErrVaultLockLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := NewMemoryDB(zap.NewNop())
+			fence := tt.fence(mdb)
+			transition := &StateTransition{From: VAULT_STATE_DOWN, To: VAULT_STATE_SEALED}
+			if err := mdb.SetVaultState(ctx, transition, fence); !errors.Is(err, tt.err) {
+				t.Fatalf("SetVaultState: got %v, want %v", err, tt.err)
+			}
+			cfg := &SealConfig{SecretShares: 1, SecretThreshold: 1}
+			if err := mdb.SetKeyholders(ctx, cfg, []string{"alice"}, fence); !errors.Is(err, tt.err) {
+				t.Fatalf("SetKeyholders: got %v, want %v", err, tt.err)
+			}
+
+			_, stateErr := mdb.GetVaultState(ctx)
+			_, cfgErr := mdb.GetSealConfig(ctx)
+			if tt.err == nil && (stateErr != nil || cfgErr != nil) {
+				t.Fatalf("fenced writes were not applied: %v, %v", stateErr, cfgErr)
+			}
+			if tt.err != nil && (stateErr != ErrNotFound || cfgErr != ErrNotFound) {
+				t.Fatalf("rejected writes were applied")
+			}
+		})
+	}
+}
+
+func TestMemoryDBKeyholders(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	cfg := &SealConfig{SecretShares: 3, SecretThreshold: 2}
+	if err := mdb.SetKeyholders(ctx, cfg, []string{"alice", "bob", "carol"}, 0); err != nil {
+		t.Fatalf("SetKeyholders: %v", err)
+	}
+	tests := []struct {
+		name      string
+		keyholder string
+		count     int
+	}{
+		{"first key", "alice", 1},
+		{"same key again", "alice", 1},
+		{"second key", "bob", 2},
+		{"third key", "carol", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := mdb.ActivateKey(ctx, KEY_PREFIX+":"+tt.keyholder)
+			if err != nil {
+				t.Fatalf("ActivateKey: %v", err)
+			}
+			if count != tt.count {
+				t.Fatalf("got %d active keys, want %d", count, tt.count)
+			}
+		})
+	}
+
+	if err := mdb.DeactivateKeys(ctx); err != nil {
+		t.Fatalf("DeactivateKeys: %v", err)
+	}
+	keyholders, err := mdb.GetKeyholders(ctx)
+	if err != nil {
+		t.Fatalf("GetKeyholders: %v", err)
+	}
+	if len(keyholders) != 3 {
+		t.Fatalf("got %d keyholders, want 3", len(keyholders))
+	}
+	for keyholder, active := range keyholders {
+		if active {
+			t.Fatalf("%s is still active", keyholder)
+		}
+	}
+
+	// the keyholders must match the seal config
+	cfg.SecretShares = 4
+	if err = mdb.SetKeyholders(ctx, cfg, []string{"alice", "bob", "carol"}, 0); err != nil {
+		t.Fatalf("SetKeyholders: %v", err)
+	}
+	if _, err = mdb.GetKeyholders(ctx); err == nil {
+		t.Fatalf("GetKeyholders accepted keyholders that do not match the seal config")
+	}
+}
+
+func TestMemoryDBStateHistory(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	for i := 0; i < STATE_HISTORY_MAX+5; i++ {
+		err := mdb.SetVaultState(ctx, &StateTransition{To: VAULT_STATE_SEALED, Reason: fmt.Sprint(i)}, 0)
+		if err != nil {
+			t.Fatalf("SetVaultState: %v", err)
+		}
+	}
+	tests := []struct {
+		name   string
+		limit  int
+		count  int
+		newest string
+	}{
+		{"limited", 3, 3, fmt.Sprint(STATE_HISTORY_MAX + 4)},
+		{"capped", STATE_HISTORY_MAX * 2, STATE_HISTORY_MAX, fmt.Sprint(STATE_HISTORY_MAX + 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := mdb.GetStateHistory(ctx, tt.limit)
+			if err != nil {
+				t.Fatalf("GetStateHistory: %v", err)
+			}
+			if len(history) != tt.count {
+				t.Fatalf("got %d transitions, want %d", len(history), tt.count)
+			}
+			if history[0].Reason != tt.newest {
+				t.Fatalf("newest transition: got %q, want %q", history[0].Reason, tt.newest)
+			}
+		})
+	}
+}
+
+func TestMemoryDBInitCode(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	code, err := mdb.CreateOrGetInitCode(ctx, 0)
+	if err != nil || code == "" {
+		t.Fatalf("CreateOrGetInitCode: %q, %v", code, err)
+	}
+	again, err := mdb.CreateOrGetInitCode(ctx, 0)
+	if err != nil || again != code {
+		t.Fatalf("second CreateOrGetInitCode: got %q, %v, want %q", again, err, code)
+	}
+	if consumed, _ := mdb.ConsumeInitCode(ctx, "other"); consumed {
+		t.Fatalf("consumed a wrong init code")
+	}
+	if consumed, _ := mdb.ConsumeInitCode(ctx, code); !consumed {
+		t.Fatalf("failed to consume the init code")
+	}
+	if consumed, _ := mdb.ConsumeInitCode(ctx, code); consumed {
+		t.Fatalf("consumed the init code twice")
+	}
+	if _, err = mdb.GetInitCode(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetInitCode after consume: got %v, want ErrNotFound", err)
+	}
+
+	if _, err = mdb.CreateOrGetInitCode(ctx, time.Nanosecond); err != nil {
+		t.Fatalf("CreateOrGetInitCode: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err = mdb.GetInitCode(ctx); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("GetInitCode after expiry: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryDBActiveNodes(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	now := time.Now()
+	heartbeats := map[string]time.Time{
+		"node-a": now,
+		"node-b": now.Add(-NODE_TTL / 2),
+		"stale":  now.Add(-2 * NODE_TTL),
+	}
+	for node, at := range heartbeats {
+		if err := mdb.Heartbeat(ctx, node, at); err != nil {
+			t.Fatalf("Heartbeat: %v", err)
+		}
+	}
+	nodes, err := mdb.GetActiveNodes(ctx, now.Add(-NODE_TTL))
+	if err != nil {
+		t.Fatalf("GetActiveNodes: %v", err)
+	}
+	if want := []string{"node-a", "node-b"}; !reflect.DeepEqual(nodes, want) {
+		t.Fatalf("got %q, want %q", nodes, want)
+	}
+	if err = mdb.RemoveNode(ctx, "node-a"); err != nil {
+		t.Fatalf("RemoveNode: %v", err)
+	}
+	nodes, _ = mdb.GetActiveNodes(ctx, now.Add(-NODE_TTL))
+	if want := []string{"node-b"}; !reflect.DeepEqual(nodes, want) {
+		t.Fatalf("got %q after RemoveNode, want %q", nodes, want)
+	}
+}
+
+func TestMemoryDBLeases(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		prior  func(mdb *MemoryDB)
+		holder string
+		want   bool
+	}{
+		{"free", func(mdb *MemoryDB) {}, "node-a", true},
+		{"held by us", func(mdb *MemoryDB) {
+			mdb.AcquireLease(ctx, "snapshot", "node-a", time.Minute)
+		}, "node-a", true},
+		{"held by another node", func(mdb *MemoryDB) {
+			mdb.AcquireLease(ctx, "snapshot", "node-b", time.Minute)
+		}, "node-a", false},
+		{"expired", func(mdb *MemoryDB) {
+			mdb.AcquireLease(ctx, "snapshot", "node-b", time.Nanosecond)
+			time.Sleep(time.Millisecond)
+		}, "node-a", true},
+		{"released", func(mdb *MemoryDB) {
+			mdb.AcquireLease(ctx, "snapshot", "node-b", time.Minute)
+			mdb.ReleaseLease(ctx, "snapshot", "node-b")
+		}, "node-a", true},
+		{"released by another node", func(mdb *MemoryDB) {
+			mdb.AcquireLease(ctx, "snapshot", "node-b", time.Minute)
+			mdb.ReleaseLease(ctx, "snapshot", "node-c")
+		}, "node-a", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := NewMemoryDB(zap.NewNop())
+			tt.prior(mdb)
+			acquired, err := mdb.AcquireLease(ctx, "snapshot", tt.holder, time.Minute)
+			if err != nil {
+				t.Fatalf("AcquireLease: %v", err)
+			}
+			if acquired != tt.want {
+				t.Fatalf("got %v, want %v", acquired, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryDBLockTokens(t *testing.T) {
+	ctx := context.Background()
+	mdb := NewMemoryDB(zap.NewNop())
+	first, err := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+	if err != nil || first == 0 {
+		t.Fatalf("AcquireLock: got %d, %v", first, err)
+	}
+	if token, _ := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute); token != 0 {
+		t.Fatalf("a held lock was taken again")
+	}
+	if err = mdb.ReleaseLock(ctx, VAULT_LOCK, first); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+	second, err := mdb.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if second <= first {
+		t.Fatalf("fencing token did not grow: %d after %d", second, first)
+	}
+	tests := []struct {
+		name  string
+		token int64
+		held  bool
+	}{
+		{"current token", second, true},
+		{"stale token", first, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			held, err := mdb.CheckLock(ctx, VAULT_LOCK, tt.token)
+			if err != nil {
+				t.Fatalf("CheckLock: %v", err)
+			}
+			if held != tt.held {
+				t.Fatalf("CheckLock: got %v, want %v", held, tt.held)
+			}
+		})
+	}
+}
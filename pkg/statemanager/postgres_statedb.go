@@ -0,0 +1,494 @@
+package statemanager
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Tables created by the keystore migrations
+const (
+	PG_STATE_TABLE      = `"keyhouse_state"`
+	PG_KEYHOLDERS_TABLE = `"keyhouse_keyholders"`
+	PG_NODES_TABLE      = `"keyhouse_nodes"`
+	PG_HISTORY_TABLE    = `"keyhouse_state_history"`
+)
+
+// PostgresDB keeps the vault state in the keystore database, so a postgres
+// deployment does not need redis. Single values live in a key/value table
+// whose rows may expire, mirroring the redis keys. Missing values are
+// reported as ErrNotFound.
+type PostgresDB struct {
+	db      *sql.DB
+	connStr string
+	logger  *zap.Logger
+}
+
+// NewPostgresDB uses db, which is owned by the postgres keystore and already
+// migrated. connStr is only used to listen for cache invalidations.
+func NewPostgresDB(logger *zap.Logger, db *sql.DB, connStr string) *PostgresDB {
+	return &PostgresDB{
+		db:      db,
+		connStr: connStr,
+		logger:  logger.With(zap.String("component", "postgresdb")),
+	}
+}
+
+func (pdb *PostgresDB) Ping(ctx context.Context) error {
+	return pdb.db.PingContext(ctx)
+}
+
+func (pdb *PostgresDB) getValue(ctx context.Context, key string) (string, error) {
+	var value string
+	err := pdb.db.QueryRowContext(ctx, `SELECT value FROM `+PG_STATE_TABLE+`
+		WHERE key = $1 AND (expires_at IS NULL OR expires_at > now())`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return value, err
+}
+
+// setValue stores value under key. A zero ttl never expires.
+func (pdb *PostgresDB) setValue(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, err := pdb.db.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` (key, value, expires_at)
+		VALUES ($1, $2, `+expiresAt(3)+`)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at`,
+		key, value, ttl.Milliseconds())
+	return err
+}
+
+func (pdb *PostgresDB) deleteValue(ctx context.Context, key string) error {
+	_, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_STATE_TABLE+` WHERE key = $1`, key)
+	return err
+}
+
+// expiresAt is the SQL expression of the expiry of a row whose ttl in
+// milliseconds is bound to parameter n, evaluated on the database clock.
+func expiresAt(n int) string {
+	return fmt.Sprintf(`CASE WHEN $%[1]d::bigint > 0 THEN now() + $%[1]d::bigint * interval '1 millisecond' END`, n)
+}
+
+func (pdb *PostgresDB) GetVaultState(ctx context.Context) (VaultState, error) {
+	state, err := pdb.getValue(ctx, STATE_KEY)
+	return VaultState(state), err
+}
+
+// SetVaultState stores the state and its history row in one transaction and
+// prunes rows beyond STATE_HISTORY_MAX.
+func (pdb *PostgresDB) SetVaultState(ctx context.Context, t *StateTransition, fence int64) error {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = fenceTx(ctx, tx, fence); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = NULL`, STATE_KEY, string(t.To))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+PG_HISTORY_TABLE+` (changed_at, from_state, to_state, actor, reason, node)
+		VALUES ($1, $2, $3, $4, $5, $6)`, t.Time, string(t.From), string(t.To), t.Actor, t.Reason, t.Node)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM `+PG_HISTORY_TABLE+`
+		WHERE id <= (SELECT max(id) FROM `+PG_HISTORY_TABLE+`) - $1`, STATE_HISTORY_MAX)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (pdb *PostgresDB) GetStateHistory(ctx context.Context, limit int) ([]*StateTransition, error) {
+	rows, err := pdb.db.QueryContext(ctx, `SELECT changed_at, from_state, to_state, actor, reason, node
+		FROM `+PG_HISTORY_TABLE+` ORDER BY id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []*StateTransition
+	for rows.Next() {
+		var t StateTransition
+		var from, to string
+		if err = rows.Scan(&t.Time, &from, &to, &t.Actor, &t.Reason, &t.Node); err != nil {
+			return nil, err
+		}
+		t.From, t.To = VaultState(from), VaultState(to)
+		history = append(history, &t)
+	}
+	return history, rows.Err()
+}
+
+// CreateOrGetInitCode stores a new init code unless a valid one exists, and
+// returns the one stored.
+func (pdb *PostgresDB) CreateOrGetInitCode(ctx context.Context, ttl time.Duration) (string, error) {
+	_, err := pdb.db.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` AS s (key, value, expires_at)
+		VALUES ($1, $2, `+expiresAt(3)+`)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		WHERE s.expires_at <= now()`,
+		INIT_CODE_KEY, uuid.New().String(), ttl.Milliseconds())
+	if err != nil {
+		pdb.logger.Error("failed to set init code", zap.Error(err))
+		return "", err
+	}
+	return pdb.GetInitCode(ctx)
+}
+
+func (pdb *PostgresDB) GetInitCode(ctx context.Context) (string, error) {
+	return pdb.getValue(ctx, INIT_CODE_KEY)
+}
+
+// ConsumeInitCode deletes the init code if it still is code.
+func (pdb *PostgresDB) ConsumeInitCode(ctx context.Context, code string) (bool, error) {
+	res, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_STATE_TABLE+`
+		WHERE key = $1 AND value = $2 AND (expires_at IS NULL OR expires_at > now())`, INIT_CODE_KEY, code)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := res.RowsAffected()
+	return deleted == 1, err
+}
+
+func (pdb *PostgresDB) RotateInitCode(ctx context.Context, ttl time.Duration) (string, error) {
+	code := uuid.New().String()
+	if err := pdb.setValue(ctx, INIT_CODE_KEY, code, ttl); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// IncrInitFailures counts a failed attempt. The window starts with the first
+// failure, like the redis implementation.
+func (pdb *PostgresDB) IncrInitFailures(ctx context.Context, window time.Duration) (int, error) {
+	var failures int
+	err := pdb.db.QueryRowContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` AS s (key, value, expires_at)
+		VALUES ($1, '1', `+expiresAt(2)+`)
+		ON CONFLICT (key) DO UPDATE SET
+			value = CASE WHEN s.expires_at <= now() THEN '1' ELSE (s.value::int + 1)::text END,
+			expires_at = CASE WHEN s.expires_at <= now() THEN EXCLUDED.expires_at ELSE s.expires_at END
+		RETURNING value::int`, INIT_FAILURES, window.Milliseconds()).Scan(&failures)
+	return failures, err
+}
+
+func (pdb *PostgresDB) GetInitFailures(ctx context.Context) (int, error) {
+	value, err := pdb.getValue(ctx, INIT_FAILURES)
+	if err == ErrNotFound {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+func (pdb *PostgresDB) ResetInitFailures(ctx context.Context) error {
+	return pdb.deleteValue(ctx, INIT_FAILURES)
+}
+
+// SetKeyholders replaces the keyholders and the seal config in a single
+// transaction.
+func (pdb *PostgresDB) SetKeyholders(ctx context.Context, cfg *SealConfig, keyholders []string, fence int64) error {
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = fenceTx(ctx, tx, fence); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM `+PG_KEYHOLDERS_TABLE); err != nil {
+		return err
+	}
+	for _, keyholder := range keyholders {
+		_, err = tx.ExecContext(ctx, `INSERT INTO `+PG_KEYHOLDERS_TABLE+` (id, active) VALUES ($1, false)`, KEY_PREFIX+":"+keyholder)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = NULL`, SEAL_CONFIG_KEY, string(encoded))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (pdb *PostgresDB) GetKeyholders(ctx context.Context) (map[string]bool, error) {
+	sealCfg, err := pdb.GetSealConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := pdb.db.QueryContext(ctx, `SELECT id, active FROM `+PG_KEYHOLDERS_TABLE)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keyholders := make(map[string]bool, sealCfg.SecretShares)
+	for rows.Next() {
+		var id string
+		var active bool
+		if err = rows.Scan(&id, &active); err != nil {
+			return nil, err
+		}
+		keyholders[id] = active
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keyholders) != sealCfg.SecretShares {
+		pdb.logger.Error("invalid number of keyholders", zap.Int("keyholder count", len(keyholders)))
+		return nil, fmt.Errorf("invalid number of keyholders: %d", len(keyholders))
+	}
+	return keyholders, nil
+}
+
+func (pdb *PostgresDB) GetActiveKeysCount(ctx context.Context) (int, error) {
+	var count int
+	err := pdb.db.QueryRowContext(ctx, `SELECT count(*) FROM `+PG_KEYHOLDERS_TABLE+` WHERE active`).Scan(&count)
+	return count, err
+}
+
+// ActivateKey locks the keyholders table so concurrent activations are
+// counted one after the other.
+func (pdb *PostgresDB) ActivateKey(ctx context.Context, keyholder string) (int, error) {
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE `+PG_KEYHOLDERS_TABLE+` IN EXCLUSIVE MODE`); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE `+PG_KEYHOLDERS_TABLE+` SET active = true WHERE id = $1`, keyholder)
+	if err != nil {
+		pdb.logger.Error("failed to activate key", zap.String("keyholder", keyholder), zap.Error(err))
+		return 0, err
+	}
+	if updated, err := res.RowsAffected(); err != nil {
+		return 0, err
+	} else if updated == 0 {
+		return 0, ErrNotFound
+	}
+	var count int
+	if err = tx.QueryRowContext(ctx, `SELECT count(*) FROM `+PG_KEYHOLDERS_TABLE+` WHERE active`).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+// CreateOrGetUnsealNonce returns the nonce of the unseal attempt in
+// progress, starting a new attempt if there is none.
+func (pdb *PostgresDB) CreateOrGetUnsealNonce(ctx context.Context) (string, error) {
+	_, err := pdb.db.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` (key, value) VALUES ($1, $2)
+		ON CONFLICT (key) DO NOTHING`, UNSEAL_NONCE_KEY, uuid.New().String())
+	if err != nil {
+		pdb.logger.Error("failed to set unseal nonce", zap.Error(err))
+		return "", err
+	}
+	return pdb.getValue(ctx, UNSEAL_NONCE_KEY)
+}
+
+func (pdb *PostgresDB) GetUnsealNonce(ctx context.Context) (string, error) {
+	nonce, err := pdb.getValue(ctx, UNSEAL_NONCE_KEY)
+	if err == ErrNotFound {
+		return "", nil
+	}
+	return nonce, err
+}
+
+func (pdb *PostgresDB) ClearUnsealNonce(ctx context.Context) error {
+	return pdb.deleteValue(ctx, UNSEAL_NONCE_KEY)
+}
+
+func (pdb *PostgresDB) DeactivateKeys(ctx context.Context) error {
+	_, err := pdb.db.ExecContext(ctx, `UPDATE `+PG_KEYHOLDERS_TABLE+` SET active = false WHERE active`)
+	return err
+}
+
+func (pdb *PostgresDB) GetSealConfig(ctx context.Context) (*SealConfig, error) {
+	value, err := pdb.getValue(ctx, SEAL_CONFIG_KEY)
+	if err != nil {
+		if err != ErrNotFound {
+			pdb.logger.Error("failed to get seal config", zap.Error(err))
+		}
+		return nil, err
+	}
+	var cfg SealConfig
+	if err = json.Unmarshal([]byte(value), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid seal config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (pdb *PostgresDB) Heartbeat(ctx context.Context, node string, at time.Time) error {
+	_, err := pdb.db.ExecContext(ctx, `INSERT INTO `+PG_NODES_TABLE+` (node, last_seen) VALUES ($1, $2)
+		ON CONFLICT (node) DO UPDATE SET last_seen = EXCLUDED.last_seen`, node, at)
+	return err
+}
+
+func (pdb *PostgresDB) RemoveNode(ctx context.Context, node string) error {
+	_, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_NODES_TABLE+` WHERE node = $1`, node)
+	return err
+}
+
+func (pdb *PostgresDB) GetActiveNodes(ctx context.Context, since time.Time) ([]string, error) {
+	// forget nodes that went away without deregistering
+	if _, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_NODES_TABLE+` WHERE last_seen < $1`, since); err != nil {
+		return nil, err
+	}
+	rows, err := pdb.db.QueryContext(ctx, `SELECT node FROM `+PG_NODES_TABLE+` WHERE last_seen >= $1 ORDER BY node`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var nodes []string
+	for rows.Next() {
+		var node string
+		if err = rows.Scan(&node); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
+
+// AcquireLease extends the lease if holder already owns it, and takes it if
+// it is free or expired.
+func (pdb *PostgresDB) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	res, err := pdb.db.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` AS s (key, value, expires_at)
+		VALUES ($1, $2, `+expiresAt(3)+`)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		WHERE s.value = EXCLUDED.value OR s.expires_at <= now()`,
+		LEASE_PREFIX+":"+name, holder, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	acquired, err := res.RowsAffected()
+	return acquired == 1, err
+}
+
+func (pdb *PostgresDB) ReleaseLease(ctx context.Context, name, holder string) error {
+	_, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_STATE_TABLE+` WHERE key = $1 AND value = $2`,
+		LEASE_PREFIX+":"+name, holder)
+	return err
+}
+
+// AcquireLock bumps the fence counter and claims the lock in one
+// transaction. The counter row stays locked until the transaction ends, so
+// acquirers are serialized, and a failed claim rolls the counter back.
+func (pdb *PostgresDB) AcquireLock(ctx context.Context, name string, ttl time.Duration) (int64, error) {
+	key := LOCK_PREFIX + ":" + name
+	tx, err := pdb.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var token int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` AS s (key, value) VALUES ($1, '1')
+		ON CONFLICT (key) DO UPDATE SET value = (s.value::bigint + 1)::text
+		RETURNING value::bigint`, key+":fence").Scan(&token)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO `+PG_STATE_TABLE+` AS s (key, value, expires_at)
+		VALUES ($1, $2, `+expiresAt(3)+`)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, expires_at = EXCLUDED.expires_at
+		WHERE s.expires_at <= now()`,
+		key, strconv.FormatInt(token, 10), ttl.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+	if acquired, err := res.RowsAffected(); err != nil || acquired == 0 {
+		return 0, err
+	}
+	return token, tx.Commit()
+}
+
+func (pdb *PostgresDB) CheckLock(ctx context.Context, name string, token int64) (bool, error) {
+	value, err := pdb.getValue(ctx, LOCK_PREFIX+":"+name)
+	if err == ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return value == strconv.FormatInt(token, 10), nil
+}
+
+// fenceTx fails with ErrVaultLockLost unless fence is 0 or still holds
+// VAULT_LOCK. The lock row stays locked until tx ends, so the lock cannot
+// change hands before the fenced writes commit.
+func fenceTx(ctx context.Context, tx *sql.Tx, fence int64) error {
+	if fence == 0 {
+		return nil
+	}
+	var held int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM `+PG_STATE_TABLE+`
+		WHERE key = $1 AND value = $2 AND expires_at > now() FOR UPDATE`,
+		LOCK_PREFIX+":"+VAULT_LOCK, strconv.FormatInt(fence, 10)).Scan(&held)
+	if err == sql.ErrNoRows {
+		return ErrVaultLockLost
+	}
+	return err
+}
+
+func (pdb *PostgresDB) ReleaseLock(ctx context.Context, name string, token int64) error {
+	_, err := pdb.db.ExecContext(ctx, `DELETE FROM `+PG_STATE_TABLE+` WHERE key = $1 AND value = $2`,
+		LOCK_PREFIX+":"+name, strconv.FormatInt(token, 10))
+	return err
+}
+
+type pgSnapshotStatus struct {
+	LastSuccess  string `json:"last_success"`
+	LastSnapshot string `json:"last_snapshot"`
+	LastFailure  string `json:"last_failure"`
+	LastError    string `json:"last_error"`
+}
+
+func (pdb *PostgresDB) SetSnapshotStatus(ctx context.Context, status *SnapshotStatus) error {
+	encoded, err := json.Marshal(&pgSnapshotStatus{
+		LastSuccess:  formatTime(status.LastSuccess),
+		LastSnapshot: status.LastSnapshot,
+		LastFailure:  formatTime(status.LastFailure),
+		LastError:    status.LastError,
+	})
+	if err != nil {
+		return err
+	}
+	return pdb.setValue(ctx, SNAPSHOT_KEY, string(encoded), 0)
+}
+
+func (pdb *PostgresDB) GetSnapshotStatus(ctx context.Context) (*SnapshotStatus, error) {
+	value, err := pdb.getValue(ctx, SNAPSHOT_KEY)
+	if err == ErrNotFound {
+		return &SnapshotStatus{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var stored pgSnapshotStatus
+	if err = json.Unmarshal([]byte(value), &stored); err != nil {
+		return nil, fmt.Errorf("invalid snapshot status: %w", err)
+	}
+	status := &SnapshotStatus{
+		LastSnapshot: stored.LastSnapshot,
+		LastError:    stored.LastError,
+	}
+	if status.LastSuccess, err = parseTime(stored.LastSuccess); err != nil {
+		return nil, fmt.Errorf("invalid last success in snapshot status: %w", err)
+	}
+	if status.LastFailure, err = parseTime(stored.LastFailure); err != nil {
+		return nil, fmt.Errorf("invalid last failure in snapshot status: %w", err)
+	}
+	return status, nil
+}
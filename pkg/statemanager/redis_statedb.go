@@ -2,6 +2,10 @@ package statemanager
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
 
 	redis "github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
@@ -29,23 +33,82 @@ func (rdb *RedisDB) Ping(ctx context.Context) error {
 	return rdb.client.Ping(ctx).Err()
 }
 
-func (rdb  *RedisDB) GetVaultState(ctx context.Context) (string, error) {
-	return rdb.client.Get(ctx, STATE_KEY).Result()
+func (rdb *RedisDB) GetVaultState(ctx context.Context) (VaultState, error) {
+	state, err := rdb.client.Get(ctx, STATE_KEY).Result()
+	return VaultState(state), notFound(err)
 }
 
-func (rdb *RedisDB) SetVaultState(ctx context.Context, state string) error {
-	return rdb.client.Set(ctx, STATE_KEY, state, 0).Err()
+// notFound reports the redis.Nil of a missing key as ErrNotFound.
+func notFound(err error) error {
+	if err == redis.Nil {
+		return ErrNotFound
+	}
+	return err
+}
+
+// fencedScript is prepended to the scripts of fenced writes. It returns 0
+// unless ARGV[1] is 0 or still holds the lock stored at KEYS[1].
+const fencedScript = `
+if ARGV[1] ~= "0" and redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+`
+
+var setVaultStateScript = redis.NewScript(fencedScript + `
+redis.call("SET", KEYS[2], ARGV[2])
+redis.call("LPUSH", KEYS[3], ARGV[3])
+redis.call("LTRIM", KEYS[3], 0, tonumber(ARGV[4]) - 1)
+return 1
+`)
+
+// SetVaultState keeps the history in a list, newest first, trimmed to
+// STATE_HISTORY_MAX entries.
+func (rdb *RedisDB) SetVaultState(ctx context.Context, t *StateTransition, fence int64) error {
+	encoded, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	written, err := setVaultStateScript.Run(ctx, rdb.client,
+		[]string{lockKeys(VAULT_LOCK)[0], STATE_KEY, STATE_HISTORY},
+		fence, string(t.To), encoded, STATE_HISTORY_MAX).Int()
+	if err != nil {
+		return err
+	}
+	if written == 0 {
+		return ErrVaultLockLost
+	}
+	return nil
+}
+
+func (rdb *RedisDB) GetStateHistory(ctx context.Context, limit int) ([]*StateTransition, error) {
+	entries, err := rdb.client.LRange(ctx, STATE_HISTORY, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*StateTransition, 0, len(entries))
+	for _, entry := range entries {
+		var t StateTransition
+		if err = json.Unmarshal([]byte(entry), &t); err != nil {
+			return nil, fmt.Errorf("invalid state history entry: %w", err)
+		}
+		history = append(history, &t)
+	}
+	return history, nil
 }
 
-func (rdb *RedisDB) CreateOrGetInitCode(ctx context.Context) (string, error) {
+func (rdb *RedisDB) CreateOrGetInitCode(ctx context.Context, ttl time.Duration) (string, error) {
 	code, err := rdb.client.Get(ctx, INIT_CODE_KEY).Result()
 	if err == redis.Nil {
 		code = uuid.New().String()
-		err = rdb.client.Set(ctx, INIT_CODE_KEY, code, 0).Err()
+		created, err := rdb.client.SetNX(ctx, INIT_CODE_KEY, code, ttl).Result()
 		if err != nil {
 			rdb.logger.Error("failed to set init code", zap.Error(err))
 			return "", err
 		}
+		// another replica created the code first
+		if !created {
+			return rdb.GetInitCode(ctx)
+		}
 	} else if err != nil {
 		rdb.logger.Error("failed to get init code", zap.Error(err))
 		return "", err
@@ -54,24 +117,112 @@ func (rdb *RedisDB) CreateOrGetInitCode(ctx context.Context) (string, error) {
 	return code, nil
 }
 
-func (rdb *RedisDB) AddKeyholder(ctx context.Context, keyholder string) error {
-	return rdb.client.Set(ctx, KEY_PREFIX+":"+keyholder, false, 0).Err()
+func (rdb *RedisDB) GetInitCode(ctx context.Context) (string, error) {
+	code, err := rdb.client.Get(ctx, INIT_CODE_KEY).Result()
+	return code, notFound(err)
+}
+
+var consumeInitCodeScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ConsumeInitCode deletes the init code if it still is code.
+func (rdb *RedisDB) ConsumeInitCode(ctx context.Context, code string) (bool, error) {
+	deleted, err := consumeInitCodeScript.Run(ctx, rdb.client, []string{INIT_CODE_KEY}, code).Int()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+func (rdb *RedisDB) RotateInitCode(ctx context.Context, ttl time.Duration) (string, error) {
+	code := uuid.New().String()
+	if err := rdb.client.Set(ctx, INIT_CODE_KEY, code, ttl).Err(); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// the failure window starts with the first failure
+var incrInitFailuresScript = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return failures
+`)
+
+func (rdb *RedisDB) IncrInitFailures(ctx context.Context, window time.Duration) (int, error) {
+	return incrInitFailuresScript.Run(ctx, rdb.client, []string{INIT_FAILURES}, window.Milliseconds()).Int()
+}
+
+func (rdb *RedisDB) GetInitFailures(ctx context.Context) (int, error) {
+	failures, err := rdb.client.Get(ctx, INIT_FAILURES).Int()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return failures, err
+}
+
+func (rdb *RedisDB) ResetInitFailures(ctx context.Context) error {
+	return rdb.client.Del(ctx, INIT_FAILURES).Err()
+}
+
+var setKeyholdersScript = redis.NewScript(fencedScript + `
+redis.call("DEL", KEYS[2], KEYS[3])
+for i = 7, #ARGV do
+	redis.call("HSET", KEYS[2], ARGV[i], "0")
+end
+redis.call("HSET", KEYS[4],
+	"secret_shares", ARGV[2],
+	"secret_threshold", ARGV[3],
+	"key_digest", ARGV[4],
+	"root_token_digest", ARGV[5],
+	"unverified", ARGV[6])
+return 1
+`)
+
+// SetKeyholders replaces the keyholders and the seal config in a single
+// transaction, so readers never see keyholders that do not match the share
+// count.
+func (rdb *RedisDB) SetKeyholders(ctx context.Context, cfg *SealConfig, keyholders []string, fence int64) error {
+	args := []interface{}{fence, cfg.SecretShares, cfg.SecretThreshold, cfg.KeyDigest, cfg.RootTokenDigest, cfg.Unverified}
+	for _, keyholder := range keyholders {
+		args = append(args, KEY_PREFIX+":"+keyholder)
+	}
+	written, err := setKeyholdersScript.Run(ctx, rdb.client,
+		[]string{lockKeys(VAULT_LOCK)[0], KEYHOLDERS_KEY, ACTIVE_KEYS, SEAL_CONFIG_KEY}, args...).Int()
+	if err != nil {
+		rdb.logger.Error("failed to store keyholders", zap.Error(err))
+		return err
+	}
+	if written == 0 {
+		return ErrVaultLockLost
+	}
+	return nil
 }
 
 func (rdb *RedisDB) GetKeyholders(ctx context.Context) (map[string]bool, error) {
-	keyholders := make(map[string]bool, 5)
-	keys, err := rdb.client.Keys(ctx, KEY_PREFIX+":*").Result()
+	sealCfg, err := rdb.GetSealConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if len(keys) != 5 {
-		rdb.logger.Error("invalid number of keyholders", zap.Int("keyholder count", len(keys)))
+	fields, err := rdb.client.HGetAll(ctx, KEYHOLDERS_KEY).Result()
+	if err != nil {
 		return nil, err
 	}
-	for _, key := range keys {
-		keyholders[key], err = rdb.client.Get(ctx, key).Bool()
+	if len(fields) != sealCfg.SecretShares {
+		rdb.logger.Error("invalid number of keyholders", zap.Int("keyholder count", len(fields)), zap.Int("secret shares", sealCfg.SecretShares))
+		return nil, fmt.Errorf("invalid number of keyholders: %d", len(fields))
+	}
+	keyholders := make(map[string]bool, len(fields))
+	for keyholder, active := range fields {
+		keyholders[keyholder], err = strconv.ParseBool(active)
 		if err != nil {
-			rdb.logger.Error("failed to parse keyholder state", zap.String("keyholder", key))
+			rdb.logger.Error("failed to parse keyholder state", zap.String("keyholder", keyholder))
 			return nil, err
 		}
 	}
@@ -79,53 +230,236 @@ func (rdb *RedisDB) GetKeyholders(ctx context.Context) (map[string]bool, error)
 	return keyholders, nil
 }
 
+// GetActiveKeysCount returns the number of distinct keys in the set of
+// activated keys.
 func (rdb *RedisDB) GetActiveKeysCount(ctx context.Context) (int, error) {
-	count, err := rdb.client.Get(ctx, ACTIVE_KEY_COUNT).Int()
+	count, err := rdb.client.SCard(ctx, ACTIVE_KEYS).Result()
 	if err != nil {
 		rdb.logger.Error("failed to get active keys count", zap.Error(err))
 		return 0, err
 	}
-	rdb.logger.Debug("fetched active keys count", zap.Int("count", count))
+	rdb.logger.Debug("fetched active keys count", zap.Int64("count", count))
+	return int(count), nil
+}
+
+// activateKeyScript marks a known keyholder active and adds it to the set of
+// activated keys in one step, so concurrent and repeated activations of the
+// same key are counted once.
+var activateKeyScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+redis.call("HSET", KEYS[1], ARGV[1], "1")
+redis.call("SADD", KEYS[2], ARGV[1])
+return redis.call("SCARD", KEYS[2])
+`)
+
+func (rdb *RedisDB) ActivateKey(ctx context.Context, keyholder string) (int, error) {
+	count, err := activateKeyScript.Run(ctx, rdb.client, []string{KEYHOLDERS_KEY, ACTIVE_KEYS}, keyholder).Int()
+	if err != nil {
+		rdb.logger.Error("failed to activate key", zap.String("keyholder", keyholder), zap.Error(err))
+		return 0, err
+	}
+	if count < 0 {
+		return 0, ErrNotFound
+	}
 	return count, nil
 }
 
-func (rdb *RedisDB) SetActiveKeysCount(ctx context.Context, count int) error {
-	return rdb.client.Set(ctx, ACTIVE_KEY_COUNT, count, 0).Err()
+// CreateOrGetUnsealNonce returns the nonce of the unseal attempt in
+// progress, starting a new attempt if there is none.
+func (rdb *RedisDB) CreateOrGetUnsealNonce(ctx context.Context) (string, error) {
+	err := rdb.client.SetNX(ctx, UNSEAL_NONCE_KEY, uuid.New().String(), 0).Err()
+	if err != nil {
+		rdb.logger.Error("failed to set unseal nonce", zap.Error(err))
+		return "", err
+	}
+	nonce, err := rdb.client.Get(ctx, UNSEAL_NONCE_KEY).Result()
+	return nonce, notFound(err)
 }
 
-func (rdb *RedisDB) ActivateKey(ctx context.Context, keyholder string) error {
-	current_state, err := rdb.client.Get(ctx, keyholder).Bool()
+func (rdb *RedisDB) GetUnsealNonce(ctx context.Context) (string, error) {
+	nonce, err := rdb.client.Get(ctx, UNSEAL_NONCE_KEY).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return nonce, err
+}
+
+func (rdb *RedisDB) ClearUnsealNonce(ctx context.Context) error {
+	return rdb.client.Del(ctx, UNSEAL_NONCE_KEY).Err()
+}
+
+// deactivateKeysScript marks every keyholder inactive and empties the set of
+// activated keys in one step, so a key activated meanwhile is not left
+// marked active outside the set.
+var deactivateKeysScript = redis.NewScript(`
+for _, keyholder in ipairs(redis.call("HKEYS", KEYS[1])) do
+	redis.call("HSET", KEYS[1], keyholder, "0")
+end
+redis.call("DEL", KEYS[2])
+return 1
+`)
+
+func (rdb *RedisDB) DeactivateKeys(ctx context.Context) error {
+	err := deactivateKeysScript.Run(ctx, rdb.client, []string{KEYHOLDERS_KEY, ACTIVE_KEYS}).Err()
 	if err != nil {
-		rdb.logger.Error("failed to get keyholder state", zap.String("keyholder", keyholder))
-		return err
+		rdb.logger.Error("failed to deactivate keyholders", zap.Error(err))
 	}
-	// if the keyholder is already active, do nothing
-	if current_state {
-		rdb.logger.Info("key is already activated", zap.String("keyholder", keyholder))
-		return nil
+	return err
+}
+
+func (rdb *RedisDB) GetSealConfig(ctx context.Context) (*SealConfig, error) {
+	fields, err := rdb.client.HGetAll(ctx, SEAL_CONFIG_KEY).Result()
+	if err != nil {
+		rdb.logger.Error("failed to get seal config", zap.Error(err))
+		return nil, err
 	}
-	return rdb.client.Set(ctx, keyholder, true, 0).Err()
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+	shares, err := strconv.Atoi(fields["secret_shares"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret shares in seal config: %w", err)
+	}
+	threshold, err := strconv.Atoi(fields["secret_threshold"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret threshold in seal config: %w", err)
+	}
+	return &SealConfig{
+		SecretShares:    shares,
+		SecretThreshold: threshold,
+		KeyDigest:       fields["key_digest"],
+		RootTokenDigest: fields["root_token_digest"],
+		Unverified:      fields["unverified"] == "1",
+	}, nil
+}
+
+func (rdb *RedisDB) Heartbeat(ctx context.Context, node string, at time.Time) error {
+	return rdb.client.ZAdd(ctx, NODES_KEY, &redis.Z{Score: float64(at.Unix()), Member: node}).Err()
+}
+
+func (rdb *RedisDB) RemoveNode(ctx context.Context, node string) error {
+	return rdb.client.ZRem(ctx, NODES_KEY, node).Err()
 }
 
-func (rdb *RedisDB) CleanKeyholders(ctx context.Context) error {
-	keyholders, err := rdb.GetKeyholders(ctx)
+func (rdb *RedisDB) GetActiveNodes(ctx context.Context, since time.Time) ([]string, error) {
+	// forget nodes that went away without deregistering
+	cutoff := strconv.FormatInt(since.Unix(), 10)
+	if err := rdb.client.ZRemRangeByScore(ctx, NODES_KEY, "-inf", "("+cutoff).Err(); err != nil {
+		return nil, err
+	}
+	return rdb.client.ZRangeByScore(ctx, NODES_KEY, &redis.ZRangeBy{Min: cutoff, Max: "+inf"}).Result()
+}
+
+// acquireLeaseScript extends the lease if holder already owns it, and takes
+// it if it is free.
+var acquireLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+var releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (rdb *RedisDB) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireLeaseScript.Run(ctx, rdb.client, []string{LEASE_PREFIX + ":" + name}, holder, ttl.Milliseconds()).Int()
 	if err != nil {
-		if err == redis.Nil {
-			rdb.logger.Debug("no keyholders found")
-			return nil
-		}
-		rdb.logger.Error("failed to get keyholders", zap.Error(err))
-		return err
+		return false, err
 	}
-	// delete all keyholders
-	for keyholder := range keyholders {
-		err = rdb.client.Del(ctx, keyholder).Err()
-		if err != nil {
-			rdb.logger.Error("failed to delete keyholder", zap.String("keyholder", keyholder))
-			return err
-		}
+	return acquired == 1, nil
+}
+
+func (rdb *RedisDB) ReleaseLease(ctx context.Context, name, holder string) error {
+	return releaseLeaseScript.Run(ctx, rdb.client, []string{LEASE_PREFIX + ":" + name}, holder).Err()
+}
+
+// acquireLockScript takes the lock if it is free and stores the next fencing
+// token in it. The fence counter never expires, so tokens keep growing across
+// holders.
+var acquireLockScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local token = redis.call("INCR", KEYS[2])
+redis.call("SET", KEYS[1], token, "PX", ARGV[1])
+return token
+`)
+
+func lockKeys(name string) []string {
+	key := LOCK_PREFIX + ":" + name
+	return []string{key, key + ":fence"}
+}
+
+func (rdb *RedisDB) AcquireLock(ctx context.Context, name string, ttl time.Duration) (int64, error) {
+	return acquireLockScript.Run(ctx, rdb.client, lockKeys(name), ttl.Milliseconds()).Int64()
+}
+
+func (rdb *RedisDB) CheckLock(ctx context.Context, name string, token int64) (bool, error) {
+	held, err := rdb.client.Get(ctx, lockKeys(name)[0]).Int64()
+	if err == redis.Nil {
+		return false, nil
 	}
-	return nil
+	if err != nil {
+		return false, err
+	}
+	return held == token, nil
+}
+
+// ReleaseLock only deletes the lock if token still holds it, the same way a
+// lease is released.
+func (rdb *RedisDB) ReleaseLock(ctx context.Context, name string, token int64) error {
+	return releaseLeaseScript.Run(ctx, rdb.client, lockKeys(name)[:1], token).Err()
+}
+
+func (rdb *RedisDB) SetSnapshotStatus(ctx context.Context, status *SnapshotStatus) error {
+	return rdb.client.HSet(ctx, SNAPSHOT_KEY,
+		"last_success", formatTime(status.LastSuccess),
+		"last_snapshot", status.LastSnapshot,
+		"last_failure", formatTime(status.LastFailure),
+		"last_error", status.LastError,
+	).Err()
+}
+
+func (rdb *RedisDB) GetSnapshotStatus(ctx context.Context) (*SnapshotStatus, error) {
+	fields, err := rdb.client.HGetAll(ctx, SNAPSHOT_KEY).Result()
+	if err != nil {
+		return nil, err
+	}
+	status := &SnapshotStatus{
+		LastSnapshot: fields["last_snapshot"],
+		LastError:    fields["last_error"],
+	}
+	if status.LastSuccess, err = parseTime(fields["last_success"]); err != nil {
+		return nil, fmt.Errorf("invalid last success in snapshot status: %w", err)
+	}
+	if status.LastFailure, err = parseTime(fields["last_failure"]); err != nil {
+		return nil, fmt.Errorf("invalid last failure in snapshot status: %w", err)
+	}
+	return status, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
 }
 
 func (rdb *RedisDB) Close() error {
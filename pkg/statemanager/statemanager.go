@@ -2,51 +2,237 @@ package statemanager
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"github.com/skriptvalley/keyhouse/pkg/shamir"
 	"go.uber.org/zap"
 )
 
 const (
-	STATE_DB         = 0
+	STATE_DB         = 0 // keystore.REDIS_STATE_DB must match
 	STATE_KEY        = "state"
 	INIT_CODE_KEY    = "init_code"
-	ACTIVE_KEY_COUNT = "active_keys_count"
+	KEYHOLDERS_KEY   = "keyholders:v1" // bump the version when the layout changes
+	ACTIVE_KEYS      = "active_keys:v1"
+	UNSEAL_NONCE_KEY = "unseal_nonce"
 	KEY_PREFIX       = "key"
+	SEAL_CONFIG_KEY  = "seal_config"
+	STATE_HISTORY    = "state_history"
+	NODES_KEY        = "nodes"
+	LEASE_PREFIX     = "lease"
+	LOCK_PREFIX      = "lock"
+	INIT_FAILURES    = "init_code_failures"
+	SNAPSHOT_KEY     = "snapshot_status"
+	MASTER_KEY_SIZE  = 32
+	ROOT_TOKEN_SIZE  = 32
+)
+
+var (
+	ErrInvalidRootToken    = errors.New("invalid root token")
+	ErrVaultNotInitialized = errors.New("vault is not initialized")
+	ErrInvalidInitCode     = errors.New("invalid init code")
+	ErrInitCodeExpired     = errors.New("init code has expired")
+	ErrTooManyInitAttempts = errors.New("too many failed init attempts")
+	ErrVaultNotSealed      = errors.New("vault is not sealed")
+	ErrUnsealNonceMismatch = errors.New("unseal nonce does not match the attempt in progress")
+	ErrVaultInitialized    = errors.New("vault is already initialized")
+	ErrVaultBusy           = errors.New("another vault operation is in progress")
+	ErrVaultLockLost       = errors.New("vault lock expired before the operation completed")
+	ErrSharesOnOtherNodes  = errors.New("unseal threshold reached but the shares were submitted to different nodes")
+	// ErrNotFound is returned by IStateDB reads of values that are not set
+	ErrNotFound = errors.New("state db value not found")
+)
+
+const (
+	// failed init attempts allowed per INIT_FAILURE_WINDOW before InitKeyhouse
+	// refuses every attempt; rotating the init code lifts the limit
+	INIT_MAX_FAILURES   = 5
+	INIT_FAILURE_WINDOW = 15 * time.Minute
 )
 
 const (
-	VAULT_STATE_DOWN   = "down"
-	VAULT_STATE_LOCKED = "locked"
-	VAULT_STATE_READY  = "ready"
+	// VAULT_LOCK serializes initialization, sealing and unsealing between
+	// replicas. Requests that find it held fail with ErrVaultBusy, except
+	// sealing, which waits up to VAULT_LOCK_WAIT.
+	VAULT_LOCK       = "vault"
+	VAULT_LOCK_TTL   = 30 * time.Second
+	VAULT_LOCK_WAIT  = 5 * time.Second
+	LOCK_RETRY_AFTER = 100 * time.Millisecond
 )
 
+const (
+	// SECRET_LOCK_PREFIX names the leases that serialize writes to a secret
+	// path between replicas
+	SECRET_LOCK_PREFIX = "secret:"
+	SECRET_LOCK_TTL    = 10 * time.Second
+	SECRET_LOCK_WAIT   = 5 * time.Second
+)
+
+var ErrSecretLocked = errors.New("secret is being written by another request")
+
+const (
+	NODE_HEARTBEAT_INTERVAL = 5 * time.Second
+	// a node that has not sent a heartbeat for NODE_TTL is considered stopped
+	NODE_TTL = 3 * NODE_HEARTBEAT_INTERVAL
+)
+
+// SealConfig describes how the master key was split between keyholders.
+type SealConfig struct {
+	SecretShares    int
+	SecretThreshold int
+	// KeyDigest is used to verify a reconstructed master key
+	KeyDigest string
+	// RootTokenDigest is used to verify the root token of privileged calls
+	RootTokenDigest string
+	// Unverified is set when the seal config was restored from a snapshot
+	// and has not been checked against the keyring by an unseal yet
+	Unverified bool
+}
+
+// SnapshotStatus records the outcome of the last scheduled snapshots.
+type SnapshotStatus struct {
+	LastSuccess  time.Time
+	LastSnapshot string
+	LastFailure  time.Time
+	LastError    string
+}
+
+// UnsealProgress describes the unseal attempt in progress. Nonce is empty
+// when no share has been submitted yet.
+type UnsealProgress struct {
+	Threshold int
+	Progress  int
+	Nonce     string
+}
+
+// Barrier is the storage encryption layer opened by the master key.
+type Barrier interface {
+	Initialize(masterKey []byte, sealDigest string) error
+	Unseal(masterKey []byte, sealDigest string) error
+	Seal()
+	Sealed() bool
+}
+
+// IStateDB stores the state shared by the replicas. Reads of values that
+// are not set return ErrNotFound.
 type IStateDB interface {
 	Ping(ctx context.Context) error
-	GetVaultState(ctx context.Context) (string, error)
-	SetVaultState(ctx context.Context, state string) error
-	CreateOrGetInitCode(ctx context.Context) (string, error)
-	AddKeyholder(ctx context.Context, keyholder string) error
+	GetVaultState(ctx context.Context) (VaultState, error)
+	// SetVaultState stores t.To as the vault state and appends t to the
+	// state history in one step, keeping the last STATE_HISTORY_MAX
+	// transitions. The write only happens while fence still holds
+	// VAULT_LOCK, and fails with ErrVaultLockLost otherwise; a fence of 0
+	// writes unconditionally.
+	SetVaultState(ctx context.Context, t *StateTransition, fence int64) error
+	// GetStateHistory returns up to limit transitions, newest first.
+	GetStateHistory(ctx context.Context, limit int) ([]*StateTransition, error)
+	CreateOrGetInitCode(ctx context.Context, ttl time.Duration) (string, error)
+	GetInitCode(ctx context.Context) (string, error)
+	ConsumeInitCode(ctx context.Context, code string) (bool, error)
+	RotateInitCode(ctx context.Context, ttl time.Duration) (string, error)
+	IncrInitFailures(ctx context.Context, window time.Duration) (int, error)
+	GetInitFailures(ctx context.Context) (int, error)
+	ResetInitFailures(ctx context.Context) error
+	// SetKeyholders replaces the keyholders and the seal config in one step,
+	// fenced like SetVaultState. Keyholders start inactive.
+	SetKeyholders(ctx context.Context, cfg *SealConfig, keyholders []string, fence int64) error
+	// GetKeyholders returns whether each keyholder is active, keyed by
+	// KEY_PREFIX:id. It fails unless there are exactly as many keyholders
+	// as the seal config has shares.
 	GetKeyholders(ctx context.Context) (map[string]bool, error)
 	GetActiveKeysCount(ctx context.Context) (int, error)
-	SetActiveKeysCount(ctx context.Context, count int) error
-	ActivateKey(ctx context.Context, keyholder string) error
-	CleanKeyholders(ctx context.Context) error
+	// ActivateKey marks keyholder as active and returns the number of
+	// distinct active keys. Activating a key twice counts it once.
+	ActivateKey(ctx context.Context, keyholder string) (int, error)
+	CreateOrGetUnsealNonce(ctx context.Context) (string, error)
+	GetUnsealNonce(ctx context.Context) (string, error)
+	ClearUnsealNonce(ctx context.Context) error
+	DeactivateKeys(ctx context.Context) error
+	GetSealConfig(ctx context.Context) (*SealConfig, error)
+	Heartbeat(ctx context.Context, node string, at time.Time) error
+	RemoveNode(ctx context.Context, node string) error
+	GetActiveNodes(ctx context.Context, since time.Time) ([]string, error)
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+	// AcquireLock takes the named lock for ttl if it is free and returns a
+	// fencing token that grows with every acquisition, or 0 if the lock is
+	// held.
+	AcquireLock(ctx context.Context, name string, ttl time.Duration) (int64, error)
+	// CheckLock reports whether token still holds the named lock.
+	CheckLock(ctx context.Context, name string, token int64) (bool, error)
+	ReleaseLock(ctx context.Context, name string, token int64) error
+	SetSnapshotStatus(ctx context.Context, status *SnapshotStatus) error
+	GetSnapshotStatus(ctx context.Context) (*SnapshotStatus, error)
 }
 
 type StateManager struct {
-	DB     IStateDB
-	logger *zap.Logger
+	DB      IStateDB
+	Barrier Barrier
+	logger  *zap.Logger
+	// node identifies this process among the replicas sharing the state db
+	node string
+	// lifetime of new init codes, zero for no expiry
+	initCodeTTL time.Duration
+
+	// unseal shares submitted so far, keyed by their x coordinate, and the
+	// nonce of the unseal attempt they belong to
+	mu           sync.Mutex
+	unsealShares map[byte][]byte
+	unsealNonce  string
 }
 
 func NewStateManager(logger *zap.Logger, host, port, password string) *StateManager {
 	rdb := NewRedisDB(logger, host, port, password)
+	return NewStateManagerWithDB(logger, rdb)
+}
+
+// NewStateManagerWithDB returns a StateManager backed by the given state db.
+func NewStateManagerWithDB(logger *zap.Logger, db IStateDB) *StateManager {
 	return &StateManager{
-		DB:     rdb,
-		logger: logger.With(zap.String("component", "statemanager")),
+		DB:           db,
+		logger:       logger.With(zap.String("component", "statemanager")),
+		node:         newNodeID(),
+		unsealShares: make(map[byte][]byte),
+	}
+}
+
+func newNodeID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
 	}
+	return fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+}
+
+// NodeID returns the identifier of this process in the state db.
+func (sm *StateManager) NodeID() string {
+	return sm.node
+}
+
+// SetInitCodeTTL sets how long new init codes stay valid. Zero disables
+// expiry.
+func (sm *StateManager) SetInitCodeTTL(ttl time.Duration) {
+	sm.initCodeTTL = ttl
+}
+
+// SetBarrier attaches the storage barrier that is initialized and unsealed
+// with the master key.
+func (sm *StateManager) SetBarrier(barrier Barrier) {
+	sm.Barrier = barrier
 }
 
 func (sm *StateManager) Ping(ctx context.Context) error {
@@ -55,47 +241,358 @@ func (sm *StateManager) Ping(ctx context.Context) error {
 
 func (sm *StateManager) InitStateDBCache(ctx context.Context) error {
 	state, err := sm.DB.GetVaultState(ctx)
-	if err == redis.Nil {
-		err = sm.DB.SetVaultState(ctx, VAULT_STATE_DOWN)
+	if errors.Is(err, ErrNotFound) {
+		err = sm.DB.SetVaultState(ctx, sm.newTransition(ctx, "", VAULT_STATE_DOWN, "state db initialized"), 0)
 		if err != nil {
 			sm.logger.Error("error setting initial vault state", zap.Error(err))
 			return err
 		}
 		sm.logger.Info(fmt.Sprintf("vault state set to %s", VAULT_STATE_DOWN))
-		err = sm.DB.SetActiveKeysCount(ctx, 0)
-		if err != nil {
-			sm.logger.Error("error creating active keys count", zap.Error(err))
-			return err
-		}
 	} else if err != nil {
 		sm.logger.Error("error getting vault state", zap.Error(err))
 		return err
+	} else if state.Unsealed() {
+		// the master key only ever lives in memory, so this node starts
+		// sealed while the other replicas keep serving
+		sm.logger.Info("vault is unsealed on other nodes, this node must be unsealed with the keyholders' keys",
+			zap.String("state", string(state)), zap.String("node", sm.node))
+	} else if state == VAULT_STATE_INITIALIZING {
+		if err = sm.recoverInit(ctx); err != nil {
+			sm.logger.Error("error recovering interrupted initialization", zap.Error(err))
+			return err
+		}
 	} else {
-		sm.logger.Info("vault state cache already initialized", zap.String("state", state))
+		sm.logger.Info("vault state cache already initialized", zap.String("state", string(state)))
 	}
 	return nil
 }
 
-func (sm *StateManager) SetVaultState(ctx context.Context, state string) error {
-	return sm.DB.SetVaultState(ctx, state)
+// SealVault drops the master key material held in memory and clears unseal
+// progress. An unsealed vault moves to sealed and keyholders must unlock it
+// again. The other nodes seal their barrier once they see the shared state,
+// see syncSeal.
+func (sm *StateManager) SealVault(ctx context.Context, reason string) error {
+	lock, err := sm.lockVault(ctx, VAULT_LOCK_WAIT)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	if state == VAULT_STATE_DOWN || state == VAULT_STATE_INITIALIZING {
+		return ErrVaultNotInitialized
+	}
+	if sm.Barrier != nil {
+		sm.Barrier.Seal()
+	}
+	if err = lock.fence(ctx); err != nil {
+		return err
+	}
+	if err = sm.discardUnsealShares(ctx); err != nil {
+		return err
+	}
+	if state.Sealed() {
+		return nil
+	}
+	return sm.transition(ctx, lock, VAULT_STATE_SEALED, reason)
+}
+
+// PrepareRestore seals the vault before a snapshot is restored over the
+// keystore. Unlike SealVault it accepts a vault that is down.
+func (sm *StateManager) PrepareRestore(ctx context.Context) error {
+	lock, err := sm.lockVault(ctx, VAULT_LOCK_WAIT)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	if sm.Barrier != nil {
+		sm.Barrier.Seal()
+	}
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	if state == VAULT_STATE_SEALED {
+		return nil
+	}
+	return sm.transition(ctx, lock, VAULT_STATE_SEALED, "snapshot restore started")
 }
 
-func (sm *StateManager) IsVaultReady(ctx context.Context) bool {
+// ResetUnseal discards every share submitted to the unseal attempt in
+// progress. Keyholders must start over with a new nonce.
+func (sm *StateManager) ResetUnseal(ctx context.Context) error {
+	lock, err := sm.lockVault(ctx, 0)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
 	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	if !state.Sealed() {
+		return ErrVaultNotSealed
+	}
+	if err = lock.fence(ctx); err != nil {
+		return err
+	}
+	if err = sm.discardUnsealShares(ctx); err != nil {
+		return err
+	}
+	sm.logger.Info("unseal attempt reset", zap.String("node", sm.node))
+	return nil
+}
+
+// vaultLock is this node's hold on VAULT_LOCK.
+type vaultLock struct {
+	sm    *StateManager
+	token int64
+}
+
+// lockVault takes VAULT_LOCK, retrying for up to wait while another node
+// holds it, and returns ErrVaultBusy if it stays held.
+func (sm *StateManager) lockVault(ctx context.Context, wait time.Duration) (*vaultLock, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		token, err := sm.DB.AcquireLock(ctx, VAULT_LOCK, VAULT_LOCK_TTL)
+		if err != nil {
+			sm.logger.Error("error acquiring vault lock", zap.Error(err))
+			return nil, err
+		}
+		if token > 0 {
+			return &vaultLock{sm: sm, token: token}, nil
+		}
+		if !time.Now().Before(deadline) {
+			sm.logger.Info("vault lock is held by another operation", zap.String("node", sm.node))
+			return nil, ErrVaultBusy
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(LOCK_RETRY_AFTER):
+		}
+	}
+}
+
+// fence fails with ErrVaultLockLost once the lock has expired, so a holder
+// that stalled past VAULT_LOCK_TTL gives up before writing. The vault state
+// and seal config are also written conditionally on the token, which closes
+// the gap between the check and the write; fence guards the other writes,
+// such as the keyring, that the state db cannot make conditional.
+func (l *vaultLock) fence(ctx context.Context) error {
+	held, err := l.sm.DB.CheckLock(ctx, VAULT_LOCK, l.token)
+	if err != nil {
+		l.sm.logger.Error("error checking vault lock", zap.Error(err))
+		return err
+	}
+	if !held {
+		l.sm.logger.Warn("vault lock lost", zap.Int64("token", l.token), zap.String("node", l.sm.node))
+		return ErrVaultLockLost
+	}
+	return nil
+}
+
+// release frees the lock even if the request context is gone, so the next
+// operation does not have to wait for it to expire.
+func (l *vaultLock) release() {
+	if err := l.sm.DB.ReleaseLock(context.Background(), VAULT_LOCK, l.token); err != nil {
+		l.sm.logger.Warn("error releasing vault lock", zap.Error(err))
+	}
+}
+
+// LockPath takes the lease on a secret path so that only one replica at a
+// time reads and rewrites its metadata. It waits up to SECRET_LOCK_WAIT and
+// returns ErrSecretLocked if the path stays locked.
+func (sm *StateManager) LockPath(path string) (func(), error) {
+	ctx := context.Background()
+	name := SECRET_LOCK_PREFIX + path
+	holder := sm.node + "-" + uuid.NewString()
+	deadline := time.Now().Add(SECRET_LOCK_WAIT)
+	for {
+		acquired, err := sm.DB.AcquireLease(ctx, name, holder, SECRET_LOCK_TTL)
+		if err != nil {
+			sm.logger.Error("error acquiring secret lock", zap.Error(err))
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+		if !time.Now().Before(deadline) {
+			sm.logger.Info("secret lock is held by another request", zap.String("path", path))
+			return nil, ErrSecretLocked
+		}
+		time.Sleep(LOCK_RETRY_AFTER)
+	}
+	return func() {
+		if err := sm.DB.ReleaseLease(context.Background(), name, holder); err != nil {
+			sm.logger.Warn("error releasing secret lock", zap.String("path", path), zap.Error(err))
+		}
+	}, nil
+}
+
+// discardUnsealShares drops the shares held by this node and ends the
+// unseal attempt for every node. Other nodes drop their shares once they see
+// the nonce change.
+func (sm *StateManager) discardUnsealShares(ctx context.Context) error {
+	sm.mu.Lock()
+	sm.dropUnsealShares()
+	sm.mu.Unlock()
+	if err := sm.DB.DeactivateKeys(ctx); err != nil {
+		sm.logger.Error("error deactivating keys", zap.Error(err))
+		return err
+	}
+	if err := sm.DB.ClearUnsealNonce(ctx); err != nil {
+		sm.logger.Error("error clearing unseal nonce", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// abandonUnsealAttempt deactivates the keys and clears the nonce of an
+// attempt whose shares failed to unseal the vault. Its shares are already
+// dropped, so the keyholders must start a new attempt rather than stay stuck
+// at the threshold.
+func (sm *StateManager) abandonUnsealAttempt(ctx context.Context, lock *vaultLock) {
+	if err := lock.fence(ctx); err != nil {
+		return
+	}
+	if err := sm.DB.DeactivateKeys(ctx); err != nil {
+		sm.logger.Error("error deactivating keys", zap.Error(err))
+	}
+	if err := sm.DB.ClearUnsealNonce(ctx); err != nil {
+		sm.logger.Error("error clearing unseal nonce", zap.Error(err))
+	}
+}
+
+// dropUnsealShares zeroes the shares held in memory. sm.mu must be held.
+func (sm *StateManager) dropUnsealShares() {
+	for x, share := range sm.unsealShares {
+		keystore.ZeroBytes(share)
+		delete(sm.unsealShares, x)
+	}
+	sm.unsealNonce = ""
+}
+
+// joinUnsealAttempt returns the nonce of the unseal attempt in progress,
+// starting one if there is none. A non-empty nonce must match it, so a
+// keyholder cannot add a share to an attempt that was reset under them.
+func (sm *StateManager) joinUnsealAttempt(ctx context.Context, nonce string) (string, error) {
+	current, err := sm.DB.CreateOrGetUnsealNonce(ctx)
+	if err != nil {
+		sm.logger.Error("error getting unseal nonce", zap.Error(err))
+		return "", err
+	}
+	if nonce != "" && nonce != current {
+		return "", ErrUnsealNonceMismatch
+	}
+	sm.mu.Lock()
+	if sm.unsealNonce != current {
+		// the attempt these shares belong to was reset on another node
+		sm.dropUnsealShares()
+		sm.unsealNonce = current
+	}
+	sm.mu.Unlock()
+	return current, nil
+}
+
+// UnsealProgress reports how many shares have been submitted towards the
+// threshold.
+func (sm *StateManager) UnsealProgress(ctx context.Context) (*UnsealProgress, error) {
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if state == VAULT_STATE_DOWN || state == VAULT_STATE_INITIALIZING {
+		return &UnsealProgress{}, nil
+	}
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	progress := &UnsealProgress{Threshold: sealCfg.SecretThreshold}
+	if state.Unsealed() && sm.nodeSealed() {
+		// this node collects its own shares while the others serve
+		sm.mu.Lock()
+		progress.Progress = len(sm.unsealShares)
+		sm.mu.Unlock()
+		return progress, nil
+	}
+	if !state.Sealed() {
+		return progress, nil
+	}
+	if progress.Progress, err = sm.DB.GetActiveKeysCount(ctx); err != nil {
+		return nil, err
+	}
+	if progress.Nonce, err = sm.DB.GetUnsealNonce(ctx); err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// IsVaultReady reports whether the vault is unsealed on this node,
+// including while it is in maintenance.
+func (sm *StateManager) IsVaultReady(ctx context.Context) bool {
+	state, err := sm.NodeState(ctx)
 	if err != nil {
 		sm.logger.Fatal("error getting vault state", zap.Error(err))
 		return false
 	}
-	return state == VAULT_STATE_READY
+	return state.Unsealed()
 }
 
+// NodeState returns the vault state as seen by this node. The shared state
+// says whether the vault has been unsealed, but each node holds its own
+// master key, so a node that started after the unseal stays sealed until
+// the keyholders unseal it too.
+func (sm *StateManager) NodeState(ctx context.Context) (VaultState, error) {
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		return "", err
+	}
+	if state.Unsealed() && sm.nodeSealed() {
+		return VAULT_STATE_SEALED, nil
+	}
+	return state, nil
+}
+
+// nodeSealed reports whether the barrier of this node is sealed. Without a
+// barrier, as in operator commands, only the shared state counts.
+func (sm *StateManager) nodeSealed() bool {
+	return sm.Barrier != nil && sm.Barrier.Sealed()
+}
+
+// syncSeal seals the barrier of this node once another node has sealed the
+// vault or restored a snapshot over it.
+func (sm *StateManager) syncSeal(ctx context.Context) {
+	// UnlockVault holds sm.mu from unsealing the barrier until the shared
+	// state says so
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.Barrier == nil || sm.Barrier.Sealed() {
+		return
+	}
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil || state.Unsealed() {
+		return
+	}
+	sm.Barrier.Seal()
+	sm.dropUnsealShares()
+	sm.logger.Info("vault sealed by another node", zap.String("state", string(state)), zap.String("node", sm.node))
+}
+
+// IsVaultLocked reports whether the vault waits for unseal keys.
 func (sm *StateManager) IsVaultLocked(ctx context.Context) bool {
 	state, err := sm.DB.GetVaultState(ctx)
 	if err != nil {
 		sm.logger.Error("error getting vault state", zap.Error(err))
 		return false
 	}
-	return state == VAULT_STATE_LOCKED
+	return state.Sealed()
 }
 
 func (sm *StateManager) IsVaultDown(ctx context.Context) bool {
@@ -107,31 +604,295 @@ func (sm *StateManager) IsVaultDown(ctx context.Context) bool {
 	return state == VAULT_STATE_DOWN
 }
 
+// SealConfigUnverified reports whether the seal config was restored from a
+// snapshot and no unseal has matched it against the keyring yet. Its root
+// token is refused until then, so the init code authorizes another restore.
+func (sm *StateManager) SealConfigUnverified(ctx context.Context) (bool, error) {
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return sealCfg.Unverified, nil
+}
+
 func (sm *StateManager) GetInitCode(ctx context.Context) (string, error) {
-	return sm.DB.CreateOrGetInitCode(ctx)
+	return sm.DB.CreateOrGetInitCode(ctx, sm.initCodeTTL)
 }
 
-func (sm *StateManager) GenerateKeys(ctx context.Context) error {
-	err := sm.DB.CleanKeyholders(ctx)
+// ValidateInitCode checks code against the current init code and consumes
+// it, so a code can authorize only one call.
+func (sm *StateManager) ValidateInitCode(ctx context.Context, code string) error {
+	expected, err := sm.checkInitCode(ctx, code)
 	if err != nil {
-		sm.logger.Error("error in cleaning existing keyholders")
 		return err
 	}
-	for i := 0; i < 5; i++ {
-		id := uuid.New().String()
-		err = sm.DB.AddKeyholder(ctx, id)
+	return sm.consumeInitCode(ctx, expected)
+}
+
+// CheckInitCode checks code against the current init code without
+// consuming it. The caller consumes it with ConsumeInitCode once the call it
+// authorizes has succeeded, so a failed call can be retried with the same
+// code.
+func (sm *StateManager) CheckInitCode(ctx context.Context, code string) error {
+	_, err := sm.checkInitCode(ctx, code)
+	return err
+}
+
+// checkInitCode checks code against the current init code without
+// consuming it, and returns the code to consume.
+func (sm *StateManager) checkInitCode(ctx context.Context, code string) (string, error) {
+	failures, err := sm.DB.GetInitFailures(ctx)
+	if err != nil {
+		return "", err
+	}
+	if failures >= INIT_MAX_FAILURES {
+		sm.logger.Warn("init attempt refused, too many failures", zap.Int("failures", failures))
+		return "", ErrTooManyInitAttempts
+	}
+
+	expected, err := sm.DB.GetInitCode(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return "", ErrInitCodeExpired
+	} else if err != nil {
+		return "", err
+	}
+	if code == "" || subtle.ConstantTimeCompare([]byte(code), []byte(expected)) != 1 {
+		failures, err = sm.DB.IncrInitFailures(ctx, INIT_FAILURE_WINDOW)
 		if err != nil {
-			sm.logger.Error("error adding keyholder")
-			return err
+			sm.logger.Error("error recording failed init attempt", zap.Error(err))
 		}
+		sm.logger.Warn("invalid init code", zap.Int("failures", failures))
+		return "", ErrInvalidInitCode
+	}
+	return expected, nil
+}
+
+// ConsumeInitCode uses up code, which CheckInitCode accepted. It fails with
+// ErrInvalidInitCode if another call consumed or rotated it meanwhile.
+func (sm *StateManager) ConsumeInitCode(ctx context.Context, code string) error {
+	return sm.consumeInitCode(ctx, code)
+}
+
+func (sm *StateManager) consumeInitCode(ctx context.Context, expected string) error {
+	// only one caller can consume the code, however many presented it
+	consumed, err := sm.DB.ConsumeInitCode(ctx, expected)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidInitCode
 	}
-	sm.SetVaultState(ctx, VAULT_STATE_LOCKED)
-	sm.logger.Info("new keys generated")
 	return nil
 }
 
-func (sm *StateManager) UnlockVault(ctx context.Context, keyholder string) (bool, error) {
-	is_ready := sm.IsVaultReady(ctx)
+// RotateInitCode replaces the init code and clears failed attempts.
+func (sm *StateManager) RotateInitCode(ctx context.Context) (string, error) {
+	code, err := sm.DB.RotateInitCode(ctx, sm.initCodeTTL)
+	if err != nil {
+		sm.logger.Error("error rotating init code", zap.Error(err))
+		return "", err
+	}
+	if err = sm.DB.ResetInitFailures(ctx); err != nil {
+		sm.logger.Error("error clearing failed init attempts", zap.Error(err))
+		return "", err
+	}
+	sm.logger.Info("init code rotated")
+	return code, nil
+}
+
+// InitVault initializes a vault that is down, consuming the init code once
+// the keys are generated, so a failed attempt can be retried with the same
+// code. When replicas race to initialize, one wins and the others get
+// ErrVaultBusy, or ErrVaultInitialized once it is done.
+func (sm *StateManager) InitVault(ctx context.Context, code string, shares, threshold int) ([]string, string, error) {
+	if err := validateShares(shares, threshold); err != nil {
+		return nil, "", err
+	}
+	lock, err := sm.lockVault(ctx, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	defer lock.release()
+	if err = sm.checkDown(ctx, lock); err != nil {
+		return nil, "", err
+	}
+	expected, err := sm.checkInitCode(ctx, code)
+	if err != nil {
+		return nil, "", err
+	}
+	keys, rootToken, err := sm.initVault(ctx, lock, shares, threshold)
+	if err != nil {
+		return nil, "", err
+	}
+	// the vault is no longer down, so the code cannot initialize it again
+	// even if it is left behind, and the keys must reach the caller either way
+	if err = sm.consumeInitCode(ctx, expected); err != nil {
+		sm.logger.Warn("error consuming init code after initialization", zap.Error(err))
+	}
+	return keys, rootToken, nil
+}
+
+// GenerateKeys creates a new master key and splits it into the given number
+// of shares, any threshold of which can unlock the vault, along with a root
+// token for privileged calls. The shares and the token are returned to the
+// caller and never persisted.
+func (sm *StateManager) GenerateKeys(ctx context.Context, shares, threshold int) ([]string, string, error) {
+	if err := validateShares(shares, threshold); err != nil {
+		return nil, "", err
+	}
+	lock, err := sm.lockVault(ctx, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	defer lock.release()
+	if err = sm.checkDown(ctx, lock); err != nil {
+		return nil, "", err
+	}
+	return sm.initVault(ctx, lock, shares, threshold)
+}
+
+// checkDown fails with ErrVaultInitialized unless the vault is down. A vault
+// left initializing by a node that stopped is moved back down first. lock
+// must be held.
+func (sm *StateManager) checkDown(ctx context.Context, lock *vaultLock) error {
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	switch state {
+	case VAULT_STATE_DOWN:
+		return nil
+	case VAULT_STATE_INITIALIZING:
+		// whoever was initializing would still hold the lock
+		return sm.transition(ctx, lock, VAULT_STATE_DOWN, "initialization interrupted")
+	default:
+		return ErrVaultInitialized
+	}
+}
+
+// recoverInit moves the vault back down if the node initializing it stopped
+// before it finished.
+func (sm *StateManager) recoverInit(ctx context.Context) error {
+	lock, err := sm.lockVault(ctx, 0)
+	if errors.Is(err, ErrVaultBusy) {
+		sm.logger.Info("vault is being initialized by another node")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer lock.release()
+	if err = sm.checkDown(ctx, lock); errors.Is(err, ErrVaultInitialized) {
+		return nil
+	}
+	return err
+}
+
+// initVault generates the keys of a vault that is down. The vault stays
+// initializing until the keyholders are stored, and goes back down if that
+// fails. lock must be held.
+func (sm *StateManager) initVault(ctx context.Context, lock *vaultLock, shares, threshold int) ([]string, string, error) {
+	if err := sm.transition(ctx, lock, VAULT_STATE_INITIALIZING, "initialization started"); err != nil {
+		return nil, "", err
+	}
+	keys, rootToken, err := sm.generateKeys(ctx, lock, shares, threshold)
+	if err != nil {
+		if resetErr := sm.transition(ctx, lock, VAULT_STATE_DOWN, "initialization failed"); resetErr != nil {
+			sm.logger.Error("error resetting vault state after failed initialization", zap.Error(resetErr))
+		}
+		return nil, "", err
+	}
+	if err = sm.transition(ctx, lock, VAULT_STATE_LOCKED, "keys generated"); err != nil {
+		return nil, "", err
+	}
+	return keys, rootToken, nil
+}
+
+func validateShares(shares, threshold int) error {
+	if shares < 1 || shares > 255 {
+		return fmt.Errorf("secret shares must be between 1 and 255")
+	}
+	if threshold < 1 || threshold > shares {
+		return fmt.Errorf("secret threshold must be between 1 and the number of shares")
+	}
+	return nil
+}
+
+// generateKeys must be called holding lock.
+func (sm *StateManager) generateKeys(ctx context.Context, lock *vaultLock, shares, threshold int) ([]string, string, error) {
+	masterKey := make([]byte, MASTER_KEY_SIZE)
+	if _, err := rand.Read(masterKey); err != nil {
+		sm.logger.Error("error generating master key", zap.Error(err))
+		return nil, "", err
+	}
+	defer keystore.ZeroBytes(masterKey)
+	parts, err := shamir.Split(masterKey, shares, threshold)
+	if err != nil {
+		sm.logger.Error("error splitting master key", zap.Error(err))
+		return nil, "", err
+	}
+	keys := make([]string, 0, len(parts))
+	keyholders := make([]string, 0, len(parts))
+	for _, part := range parts {
+		keyholders = append(keyholders, keyDigest(part))
+		keys = append(keys, base64.StdEncoding.EncodeToString(part))
+	}
+	rootToken, err := newRootToken()
+	if err != nil {
+		sm.logger.Error("error generating root token", zap.Error(err))
+		return nil, "", err
+	}
+	sealCfg := &SealConfig{
+		SecretShares:    shares,
+		SecretThreshold: threshold,
+		KeyDigest:       keyDigest(masterKey),
+		RootTokenDigest: keyDigest([]byte(rootToken)),
+	}
+	if err = lock.fence(ctx); err != nil {
+		return nil, "", err
+	}
+	if err = sm.Barrier.Initialize(masterKey, sealDigest(sealCfg, keyholders)); err != nil {
+		sm.logger.Error("error initializing barrier", zap.Error(err))
+		return nil, "", err
+	}
+	err = sm.DB.SetKeyholders(ctx, sealCfg, keyholders, lock.token)
+	if err != nil {
+		sm.logger.Error("error storing keyholders", zap.Error(err))
+		return nil, "", err
+	}
+	if err = sm.DB.ClearUnsealNonce(ctx); err != nil {
+		sm.logger.Error("error clearing unseal nonce", zap.Error(err))
+		return nil, "", err
+	}
+	sm.mu.Lock()
+	sm.dropUnsealShares()
+	sm.mu.Unlock()
+	sm.logger.Info("new keys generated", zap.Int("shares", shares), zap.Int("threshold", threshold))
+	return keys, rootToken, nil
+}
+
+// UnlockVault submits an unseal key share to the attempt identified by
+// nonce, or to the attempt in progress when nonce is empty. The master key
+// is rebuilt once the configured threshold of distinct shares has been
+// collected.
+func (sm *StateManager) UnlockVault(ctx context.Context, key, nonce string) (bool, error) {
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return false, err
+	}
+	is_ready := state.Unsealed() && !sm.nodeSealed()
+	if is_ready {
+		return is_ready, nil
+	}
+	part, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(part) != MASTER_KEY_SIZE+shamir.SHARE_OVERHEAD {
+		sm.logger.Info("malformed unseal key")
+		return is_ready, fmt.Errorf("invalid_key")
+	}
+	keyholder := fmt.Sprintf("%v:%v", KEY_PREFIX, keyDigest(part))
 	keyholders, err := sm.DB.GetKeyholders(ctx)
 	if err != nil {
 		sm.logger.Error("error getting keyholders")
@@ -141,31 +902,279 @@ func (sm *StateManager) UnlockVault(ctx context.Context, keyholder string) (bool
 		sm.logger.Info("keyholder id not found")
 		return is_ready, fmt.Errorf("invalid_key")
 	}
-	err = sm.DB.ActivateKey(ctx, keyholder)
+	if state.Unsealed() {
+		return sm.unsealNode(ctx, part, keyholders)
+	}
+	if _, err = sm.joinUnsealAttempt(ctx, nonce); err != nil {
+		return false, err
+	}
+	active_keys, err := sm.DB.ActivateKey(ctx, keyholder)
 	if err != nil {
 		sm.logger.Error("error activating key")
 		return false, err
 	}
-	sm.logger.Info("keyholder activated")
-	active_keys, err := sm.DB.GetActiveKeysCount(ctx)
+	sm.logger.Info("keyholder activated", zap.Int("active_keys", active_keys))
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
 	if err != nil {
-		sm.logger.Error("error getting active keys count")
+		sm.logger.Error("error getting seal config", zap.Error(err))
 		return false, err
 	}
-	active_keys += 1
-	err = sm.DB.SetActiveKeysCount(ctx, active_keys)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.unsealShares[part[len(part)-1]] = part
+	if active_keys < sealCfg.SecretThreshold {
+		return false, nil
+	}
+	if len(sm.unsealShares) < sealCfg.SecretThreshold {
+		// the master key can only be rebuilt on the node holding the shares,
+		// so the keyholders have to submit them again to this one
+		sm.logger.Warn("unseal threshold reached but shares were submitted to other nodes",
+			zap.Int("active_keys", active_keys), zap.Int("local_shares", len(sm.unsealShares)))
+		return false, fmt.Errorf("%w, %d of %d are on node %s", ErrSharesOnOtherNodes,
+			len(sm.unsealShares), sealCfg.SecretThreshold, sm.node)
+	}
+	// the shares are kept if another operation holds the lock, so the
+	// last keyholder can submit again
+	lock, err := sm.lockVault(ctx, 0)
 	if err != nil {
-		sm.logger.Error("error updating active keys count", zap.Int("new count", active_keys))
 		return false, err
 	}
-	if !is_ready && active_keys >= 3 {
-		err = sm.DB.SetVaultState(ctx, VAULT_STATE_READY)
-		if err != nil {
-			sm.logger.Error("error setting vault state")
+	defer lock.release()
+	current, err := sm.DB.GetUnsealNonce(ctx)
+	if err != nil {
+		sm.logger.Error("error getting unseal nonce", zap.Error(err))
+		return false, err
+	}
+	if current != sm.unsealNonce {
+		// the attempt was reset or the vault sealed before the lock was taken
+		sm.dropUnsealShares()
+		return false, ErrUnsealNonceMismatch
+	}
+	parts := make([][]byte, 0, len(sm.unsealShares))
+	for _, share := range sm.unsealShares {
+		parts = append(parts, share)
+	}
+	sm.unsealShares = make(map[byte][]byte)
+	sm.unsealNonce = ""
+	masterKey, err := shamir.Combine(parts)
+	if err != nil {
+		sm.logger.Error("error combining unseal keys", zap.Error(err))
+		sm.abandonUnsealAttempt(ctx, lock)
+		return false, err
+	}
+	defer keystore.ZeroBytes(masterKey)
+	if subtle.ConstantTimeCompare([]byte(keyDigest(masterKey)), []byte(sealCfg.KeyDigest)) != 1 {
+		sm.logger.Error("reconstructed master key does not match")
+		sm.abandonUnsealAttempt(ctx, lock)
+		return false, fmt.Errorf("failed to reconstruct master key")
+	}
+	ids := keyholderIDs(keyholders)
+	err = sm.Barrier.Unseal(masterKey, sealDigest(sealCfg, ids))
+	if errors.Is(err, keystore.ErrSealDigestMismatch) {
+		sm.logger.Error("seal config does not match the keyring, it may have been tampered with")
+		sm.abandonUnsealAttempt(ctx, lock)
+		return false, err
+	} else if err != nil {
+		sm.logger.Error("error unsealing barrier", zap.Error(err))
+		sm.abandonUnsealAttempt(ctx, lock)
+		return false, err
+	}
+	if sealCfg.Unverified {
+		// the restored seal config is now known to come with the keystore
+		sealCfg.Unverified = false
+		if err = sm.DB.SetKeyholders(ctx, sealCfg, ids, lock.token); err != nil {
+			sm.logger.Error("error storing verified seal config", zap.Error(err))
+			sm.Barrier.Seal()
 			return false, err
 		}
-		sm.logger.Info("vault unlocked", zap.Int("active_keys", active_keys))
-		is_ready = true
 	}
-	return is_ready, nil
+	if err = sm.transition(ctx, lock, VAULT_STATE_READY, "unseal threshold reached"); err != nil {
+		sm.Barrier.Seal()
+		return false, err
+	}
+	if err = sm.DB.ClearUnsealNonce(ctx); err != nil {
+		sm.logger.Error("error clearing unseal nonce", zap.Error(err))
+	}
+	sm.logger.Info("vault unlocked", zap.Int("active_keys", active_keys))
+	return true, nil
+}
+
+// unsealNode unseals the barrier of this node while the vault is already
+// unsealed on others. The shares are only collected on this node, and the
+// shared state and unseal attempt are left alone.
+func (sm *StateManager) unsealNode(ctx context.Context, part []byte, keyholders map[string]bool) (bool, error) {
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
+	if err != nil {
+		sm.logger.Error("error getting seal config", zap.Error(err))
+		return false, err
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if sm.unsealNonce != "" {
+		// shares of a cluster unseal that another node finished
+		sm.dropUnsealShares()
+	}
+	sm.unsealShares[part[len(part)-1]] = part
+	if len(sm.unsealShares) < sealCfg.SecretThreshold {
+		sm.logger.Info("node unseal share accepted", zap.Int("shares", len(sm.unsealShares)), zap.String("node", sm.node))
+		return false, nil
+	}
+	parts := make([][]byte, 0, len(sm.unsealShares))
+	for _, share := range sm.unsealShares {
+		parts = append(parts, share)
+	}
+	sm.unsealShares = make(map[byte][]byte)
+	masterKey, err := shamir.Combine(parts)
+	if err != nil {
+		sm.logger.Error("error combining unseal keys", zap.Error(err))
+		return false, err
+	}
+	defer keystore.ZeroBytes(masterKey)
+	if subtle.ConstantTimeCompare([]byte(keyDigest(masterKey)), []byte(sealCfg.KeyDigest)) != 1 {
+		sm.logger.Error("reconstructed master key does not match")
+		return false, fmt.Errorf("failed to reconstruct master key")
+	}
+	if err = sm.Barrier.Unseal(masterKey, sealDigest(sealCfg, keyholderIDs(keyholders))); err != nil {
+		sm.logger.Error("error unsealing barrier", zap.Error(err))
+		return false, err
+	}
+	sm.logger.Info("node unsealed", zap.String("node", sm.node))
+	return true, nil
+}
+
+// RunHeartbeat registers this node as running until ctx is done, so
+// operator commands can tell whether any server is still using the keystore.
+func (sm *StateManager) RunHeartbeat(ctx context.Context) {
+	node := sm.node
+	ticker := time.NewTicker(NODE_HEARTBEAT_INTERVAL)
+	defer ticker.Stop()
+	for {
+		if err := sm.DB.Heartbeat(ctx, node, time.Now()); err != nil && ctx.Err() == nil {
+			sm.logger.Warn("failed to send node heartbeat", zap.String("node", node), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			if err := sm.DB.RemoveNode(context.Background(), node); err != nil {
+				sm.logger.Warn("failed to deregister node", zap.String("node", node), zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			sm.syncSeal(ctx)
+		}
+	}
+}
+
+// ActiveNodes returns the servers that sent a heartbeat within NODE_TTL.
+func (sm *StateManager) ActiveNodes(ctx context.Context) ([]string, error) {
+	return sm.DB.GetActiveNodes(ctx, time.Now().Add(-NODE_TTL))
+}
+
+// VerifyRootToken checks token against the root token generated when the
+// vault was initialized.
+func (sm *StateManager) VerifyRootToken(ctx context.Context, token string) error {
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
+	if errors.Is(err, ErrNotFound) {
+		return ErrInvalidRootToken
+	} else if err != nil {
+		return err
+	}
+	if token == "" || sealCfg.RootTokenDigest == "" {
+		return ErrInvalidRootToken
+	}
+	if sealCfg.Unverified {
+		// the root token digest of a restored snapshot is only trusted once
+		// an unseal has matched it against the keyring
+		sm.logger.Warn("root token refused until the restored seal config is verified by an unseal")
+		return ErrInvalidRootToken
+	}
+	if subtle.ConstantTimeCompare([]byte(keyDigest([]byte(token))), []byte(sealCfg.RootTokenDigest)) != 1 {
+		return ErrInvalidRootToken
+	}
+	return nil
+}
+
+// ExportSealState returns the seal config and keyholder ids needed to unseal
+// a copy of the keystore with the same unseal shares.
+func (sm *StateManager) ExportSealState(ctx context.Context) (*SealConfig, []string, error) {
+	sealCfg, err := sm.DB.GetSealConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyholders, err := sm.DB.GetKeyholders(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealCfg, keyholderIDs(keyholders), nil
+}
+
+// keyholderIDs returns the sorted ids of the keyholders returned by
+// GetKeyholders.
+func keyholderIDs(keyholders map[string]bool) []string {
+	ids := make([]string, 0, len(keyholders))
+	for keyholder := range keyholders {
+		ids = append(ids, strings.TrimPrefix(keyholder, KEY_PREFIX+":"))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RestoreSealState replaces the seal config and keyholders, as exported by
+// ExportSealState, and leaves the vault sealed. PrepareRestore must have
+// sealed the vault and the keystore must already be restored. The seal
+// config is marked unverified until an unseal matches it against the
+// restored keyring.
+func (sm *StateManager) RestoreSealState(ctx context.Context, sealCfg *SealConfig, keyholders []string) error {
+	if len(keyholders) != sealCfg.SecretShares {
+		return fmt.Errorf("seal config expects %d keyholders, got %d", sealCfg.SecretShares, len(keyholders))
+	}
+	lock, err := sm.lockVault(ctx, VAULT_LOCK_WAIT)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	restored := *sealCfg
+	restored.Unverified = true
+	if err = sm.DB.SetKeyholders(ctx, &restored, keyholders, lock.token); err != nil {
+		sm.logger.Error("error storing keyholders", zap.Error(err))
+		return err
+	}
+	if err = sm.discardUnsealShares(ctx); err != nil {
+		return err
+	}
+	if err = sm.transition(ctx, lock, VAULT_STATE_SEALED, "snapshot restored"); err != nil {
+		return err
+	}
+	sm.logger.Info("seal state restored", zap.Int("shares", sealCfg.SecretShares), zap.Int("threshold", sealCfg.SecretThreshold))
+	return nil
+}
+
+func newRootToken() (string, error) {
+	token := make([]byte, ROOT_TOKEN_SIZE)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// sealDigest covers everything a snapshot carries outside the keystore, so
+// the keyring can vouch for it.
+func sealDigest(cfg *SealConfig, keyholders []string) string {
+	ids := append([]string(nil), keyholders...)
+	sort.Strings(ids)
+	encoded, _ := json.Marshal(struct {
+		SecretShares    int      `json:"secret_shares"`
+		SecretThreshold int      `json:"secret_threshold"`
+		KeyDigest       string   `json:"key_digest"`
+		RootTokenDigest string   `json:"root_token_digest"`
+		Keyholders      []string `json:"keyholders"`
+	}{cfg.SecretShares, cfg.SecretThreshold, cfg.KeyDigest, cfg.RootTokenDigest, ids})
+	return keyDigest(encoded)
+}
+
+// keyDigest returns the hex sha256 of key. It also identifies keyholders by
+// their share.
+func keyDigest(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
 }
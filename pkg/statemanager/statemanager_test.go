@@ -0,0 +1,547 @@
+package statemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+	"go.uber.org/zap"
+)
+
+func newTestStateManager(t *testing.T, store keystore.BackendKeyStore) *StateManager {
+	t.Helper()
+	sm := NewStateManagerWithDB(zap.NewNop(), NewMemoryDB(zap.NewNop()))
+	sm.SetBarrier(keystore.NewBarrier(store))
+	if err := sm.InitStateDBCache(context.Background()); err != nil {
+		t.Fatalf("InitStateDBCache: %v", err)
+	}
+	return sm
+}
+
+// unlock submits shares until the vault reports ready.
+func unlock(t *testing.T, sm *StateManager, shares []string) {
+	t.Helper()
+	ctx := context.Background()
+	for i, share := range shares {
+		ready, err := sm.UnlockVault(ctx, share, "")
+		if err != nil {
+			t.Fatalf("UnlockVault: %v", err)
+		}
+		if ready != (i == len(shares)-1) {
+			t.Fatalf("ready after %d shares: %v", i+1, ready)
+		}
+	}
+}
+
+func TestVerifyRootToken(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	if err := sm.VerifyRootToken(ctx, "anything"); !errors.Is(err, ErrInvalidRootToken) {
+		t.Fatalf("before init: got %v, want %v", err, ErrInvalidRootToken)
+	}
+	_, rootToken, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"root token", rootToken, nil},
+		{"wrong token", rootToken + "x", ErrInvalidRootToken},
+		{"empty token", "", ErrInvalidRootToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := sm.VerifyRootToken(ctx, tt.token); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRestoreSealState(t *testing.T) {
+	ctx := context.Background()
+	store := keystore.NewMemoryStore()
+	sm := newTestStateManager(t, store)
+	shares, rootToken, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	sealCfg, keyholders, err := sm.ExportSealState(ctx)
+	if err != nil {
+		t.Fatalf("ExportSealState: %v", err)
+	}
+
+	// a fresh state db over a copy of the keystore unseals with the old shares
+	restored := newTestStateManager(t, store)
+	if err = restored.RestoreSealState(ctx, sealCfg, keyholders[:2]); err == nil {
+		t.Fatalf("RestoreSealState accepted too few keyholders")
+	}
+	if err = restored.RestoreSealState(ctx, sealCfg, keyholders); err != nil {
+		t.Fatalf("RestoreSealState: %v", err)
+	}
+	if !restored.IsVaultLocked(ctx) {
+		t.Fatalf("vault is not locked after RestoreSealState")
+	}
+	// the restored root token is refused until an unseal verifies it
+	if unverified, err := restored.SealConfigUnverified(ctx); err != nil || !unverified {
+		t.Fatalf("SealConfigUnverified before unseal: %v, %v", unverified, err)
+	}
+	if err = restored.VerifyRootToken(ctx, rootToken); !errors.Is(err, ErrInvalidRootToken) {
+		t.Fatalf("VerifyRootToken before unseal: got %v, want %v", err, ErrInvalidRootToken)
+	}
+	unlock(t, restored, shares[1:])
+	if !restored.IsVaultReady(ctx) {
+		t.Fatalf("vault is not ready after unlocking")
+	}
+	if unverified, err := restored.SealConfigUnverified(ctx); err != nil || unverified {
+		t.Fatalf("SealConfigUnverified after unseal: %v, %v", unverified, err)
+	}
+	if err = restored.VerifyRootToken(ctx, rootToken); err != nil {
+		t.Fatalf("VerifyRootToken after unseal: %v", err)
+	}
+}
+
+func TestRestoreTamperedSealState(t *testing.T) {
+	ctx := context.Background()
+	store := keystore.NewMemoryStore()
+	sm := newTestStateManager(t, store)
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	sealCfg, keyholders, err := sm.ExportSealState(ctx)
+	if err != nil {
+		t.Fatalf("ExportSealState: %v", err)
+	}
+
+	// a root token digest swapped into the snapshot must not survive an
+	// unseal
+	tampered := *sealCfg
+	tampered.RootTokenDigest = keyDigest([]byte("forged"))
+	restored := newTestStateManager(t, store)
+	if err = restored.RestoreSealState(ctx, &tampered, keyholders); err != nil {
+		t.Fatalf("RestoreSealState: %v", err)
+	}
+	if _, err = restored.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if _, err = restored.UnlockVault(ctx, shares[1], ""); !errors.Is(err, keystore.ErrSealDigestMismatch) {
+		t.Fatalf("UnlockVault: got %v, want %v", err, keystore.ErrSealDigestMismatch)
+	}
+	if restored.IsVaultReady(ctx) {
+		t.Fatalf("vault is ready with a tampered seal config")
+	}
+	if err = restored.VerifyRootToken(ctx, "forged"); !errors.Is(err, ErrInvalidRootToken) {
+		t.Fatalf("VerifyRootToken: got %v, want %v", err, ErrInvalidRootToken)
+	}
+}
+
+func TestSealVault(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	if err := sm.SealVault(ctx, "test"); !errors.Is(err, ErrVaultNotInitialized) {
+		t.Fatalf("before init: got %v, want %v", err, ErrVaultNotInitialized)
+	}
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	unlock(t, sm, shares[:2])
+	if err = sm.SealVault(ctx, "test"); err != nil {
+		t.Fatalf("SealVault: %v", err)
+	}
+	if !sm.IsVaultLocked(ctx) || !sm.Barrier.Sealed() {
+		t.Fatalf("vault is not sealed after SealVault")
+	}
+	keyholders, err := sm.DB.GetKeyholders(ctx)
+	if err != nil {
+		t.Fatalf("GetKeyholders: %v", err)
+	}
+	for keyholder, active := range keyholders {
+		if active {
+			t.Fatalf("keyholder %s is still active", keyholder)
+		}
+	}
+	// the same shares unseal the vault again
+	unlock(t, sm, shares[1:])
+}
+
+func TestUnlockVaultAbandonsFailedAttempt(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		tamper func(cfg *SealConfig)
+		err    error
+	}{
+		{"key digest mismatch", func(cfg *SealConfig) { cfg.KeyDigest = keyDigest([]byte("forged")) }, nil},
+		{"seal digest mismatch", func(cfg *SealConfig) { cfg.RootTokenDigest = keyDigest([]byte("forged")) }, keystore.ErrSealDigestMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := keystore.NewMemoryStore()
+			sm := newTestStateManager(t, store)
+			shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+			if err != nil {
+				t.Fatalf("GenerateKeys: %v", err)
+			}
+			sealCfg, keyholders, err := sm.ExportSealState(ctx)
+			if err != nil {
+				t.Fatalf("ExportSealState: %v", err)
+			}
+			tt.tamper(sealCfg)
+			restored := newTestStateManager(t, store)
+			if err = restored.RestoreSealState(ctx, sealCfg, keyholders); err != nil {
+				t.Fatalf("RestoreSealState: %v", err)
+			}
+			if _, err = restored.UnlockVault(ctx, shares[0], ""); err != nil {
+				t.Fatalf("UnlockVault: %v", err)
+			}
+			_, err = restored.UnlockVault(ctx, shares[1], "")
+			if err == nil || (tt.err != nil && !errors.Is(err, tt.err)) {
+				t.Fatalf("UnlockVault: got %v, want %v", err, tt.err)
+			}
+
+			// the failed attempt is gone, so the next share starts a new one
+			if count, err := restored.DB.GetActiveKeysCount(ctx); err != nil || count != 0 {
+				t.Fatalf("GetActiveKeysCount: got %d, %v, want 0", count, err)
+			}
+			if nonce, err := restored.DB.GetUnsealNonce(ctx); err != nil || nonce != "" {
+				t.Fatalf("GetUnsealNonce: got %q, %v, want none", nonce, err)
+			}
+			if ready, err := restored.UnlockVault(ctx, shares[2], ""); err != nil || ready {
+				t.Fatalf("UnlockVault of a new attempt: %v, %v", ready, err)
+			}
+		})
+	}
+}
+
+func TestNodeSeal(t *testing.T) {
+	ctx := context.Background()
+	store := keystore.NewMemoryStore()
+	sm := newTestStateManager(t, store)
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	unlock(t, sm, shares[:2])
+
+	// a node started over the same state db is sealed while the others
+	// keep serving
+	node := NewStateManagerWithDB(zap.NewNop(), sm.DB)
+	node.SetBarrier(keystore.NewBarrier(store))
+	if err = node.InitStateDBCache(ctx); err != nil {
+		t.Fatalf("InitStateDBCache: %v", err)
+	}
+	if state, err := node.NodeState(ctx); err != nil || state != VAULT_STATE_SEALED {
+		t.Fatalf("NodeState: got %v, %v, want %v", state, err, VAULT_STATE_SEALED)
+	}
+	if !sm.IsVaultReady(ctx) {
+		t.Fatalf("vault is not ready on the first node after a restart")
+	}
+	unlock(t, node, shares[1:])
+	if !node.IsVaultReady(ctx) {
+		t.Fatalf("vault is not ready on the new node after unlocking")
+	}
+
+	// sealing one node seals the others once they see the shared state
+	if err = sm.SealVault(ctx, "test"); err != nil {
+		t.Fatalf("SealVault: %v", err)
+	}
+	node.syncSeal(ctx)
+	if !node.Barrier.Sealed() {
+		t.Fatalf("barrier of the new node is unsealed after SealVault")
+	}
+}
+
+func TestValidateInitCode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		prepare func(sm *StateManager, code string) string
+		err     error
+	}{
+		{"valid code", func(sm *StateManager, code string) string { return code }, nil},
+		{"wrong code", func(sm *StateManager, code string) string { return "wrong" }, ErrInvalidInitCode},
+		{"empty code", func(sm *StateManager, code string) string { return "" }, ErrInvalidInitCode},
+		{"already used", func(sm *StateManager, code string) string {
+			sm.ValidateInitCode(ctx, code)
+			return code
+		}, ErrInitCodeExpired},
+		{"too many failures", func(sm *StateManager, code string) string {
+			for i := 0; i < INIT_MAX_FAILURES; i++ {
+				sm.ValidateInitCode(ctx, "wrong")
+			}
+			return code
+		}, ErrTooManyInitAttempts},
+		{"rotated after failures", func(sm *StateManager, code string) string {
+			for i := 0; i < INIT_MAX_FAILURES; i++ {
+				sm.ValidateInitCode(ctx, "wrong")
+			}
+			rotated, _ := sm.RotateInitCode(ctx)
+			return rotated
+		}, nil},
+		{"old code after rotation", func(sm *StateManager, code string) string {
+			sm.RotateInitCode(ctx)
+			return code
+		}, ErrInvalidInitCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newTestStateManager(t, keystore.NewMemoryStore())
+			code, err := sm.GetInitCode(ctx)
+			if err != nil {
+				t.Fatalf("GetInitCode: %v", err)
+			}
+			if err = sm.ValidateInitCode(ctx, tt.prepare(sm, code)); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// failingBarrier fails the first Initialize, as when the keystore is
+// briefly unreachable.
+type failingBarrier struct {
+	Barrier
+	failed bool
+}
+
+func (b *failingBarrier) Initialize(masterKey []byte, sealDigest string) error {
+	if !b.failed {
+		b.failed = true
+		return errors.New("keystore unavailable")
+	}
+	return b.Barrier.Initialize(masterKey, sealDigest)
+}
+
+func TestInitVaultRetry(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	sm.SetBarrier(&failingBarrier{Barrier: keystore.NewBarrier(keystore.NewMemoryStore())})
+	code, err := sm.GetInitCode(ctx)
+	if err != nil {
+		t.Fatalf("GetInitCode: %v", err)
+	}
+	if _, _, err = sm.InitVault(ctx, code, 1, 1); err == nil {
+		t.Fatalf("InitVault succeeded with a failing barrier")
+	}
+	if !sm.IsVaultDown(ctx) {
+		t.Fatalf("vault is not down after a failed init")
+	}
+
+	// the failed attempt left the code for a retry, which consumes it
+	if _, _, err = sm.InitVault(ctx, code, 1, 1); err != nil {
+		t.Fatalf("InitVault retry: %v", err)
+	}
+	if err = sm.ValidateInitCode(ctx, code); !errors.Is(err, ErrInitCodeExpired) {
+		t.Fatalf("ValidateInitCode after init: got %v, want %v", err, ErrInitCodeExpired)
+	}
+}
+
+func TestInitCodeExpiry(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	sm.SetInitCodeTTL(time.Nanosecond)
+	code, err := sm.GetInitCode(ctx)
+	if err != nil {
+		t.Fatalf("GetInitCode: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err = sm.ValidateInitCode(ctx, code); !errors.Is(err, ErrInitCodeExpired) {
+		t.Fatalf("got %v, want %v", err, ErrInitCodeExpired)
+	}
+}
+
+func TestUnsealNonce(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if progress, _ := sm.UnsealProgress(ctx); progress.Nonce != "" || progress.Progress != 0 {
+		t.Fatalf("got %+v before the first share", progress)
+	}
+	if _, err = sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	progress, err := sm.UnsealProgress(ctx)
+	if err != nil {
+		t.Fatalf("UnsealProgress: %v", err)
+	}
+	if progress.Nonce == "" || progress.Progress != 1 || progress.Threshold != 2 {
+		t.Fatalf("got %+v after the first share", progress)
+	}
+	if _, err = sm.UnlockVault(ctx, shares[1], "other"); !errors.Is(err, ErrUnsealNonceMismatch) {
+		t.Fatalf("wrong nonce: got %v, want %v", err, ErrUnsealNonceMismatch)
+	}
+
+	if err = sm.ResetUnseal(ctx); err != nil {
+		t.Fatalf("ResetUnseal: %v", err)
+	}
+	if reset, _ := sm.UnsealProgress(ctx); reset.Nonce != "" || reset.Progress != 0 {
+		t.Fatalf("got %+v after ResetUnseal", reset)
+	}
+	// shares of the reset attempt no longer count
+	if _, err = sm.UnlockVault(ctx, shares[1], progress.Nonce); !errors.Is(err, ErrUnsealNonceMismatch) {
+		t.Fatalf("nonce of a reset attempt: got %v, want %v", err, ErrUnsealNonceMismatch)
+	}
+	unlock(t, sm, shares[1:])
+	if err = sm.ResetUnseal(ctx); !errors.Is(err, ErrVaultNotSealed) {
+		t.Fatalf("ResetUnseal when ready: got %v, want %v", err, ErrVaultNotSealed)
+	}
+}
+
+func TestUnsealResetOnOtherNode(t *testing.T) {
+	ctx := context.Background()
+	store := keystore.NewMemoryStore()
+	sm := newTestStateManager(t, store)
+	other := NewStateManagerWithDB(zap.NewNop(), sm.DB)
+	other.SetBarrier(keystore.NewBarrier(store))
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if _, err = sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if err = other.ResetUnseal(ctx); err != nil {
+		t.Fatalf("ResetUnseal: %v", err)
+	}
+	// the share held in memory belongs to the reset attempt
+	ready, err := sm.UnlockVault(ctx, shares[1], "")
+	if err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if ready {
+		t.Fatalf("vault unsealed with a share of a reset attempt")
+	}
+	if progress, _ := sm.UnsealProgress(ctx); progress.Progress != 1 {
+		t.Fatalf("got progress %d, want 1", progress.Progress)
+	}
+}
+
+func TestUnlockVaultCountsDistinctShares(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		ready, err := sm.UnlockVault(ctx, shares[0], "")
+		if err != nil {
+			t.Fatalf("UnlockVault: %v", err)
+		}
+		if ready {
+			t.Fatalf("vault unsealed with a single share submitted twice")
+		}
+	}
+	if progress, _ := sm.UnsealProgress(ctx); progress.Progress != 1 {
+		t.Fatalf("got progress %d, want 1", progress.Progress)
+	}
+	unlock(t, sm, shares[1:2])
+}
+
+func TestUnlockVaultSharesOnOtherNodes(t *testing.T) {
+	ctx := context.Background()
+	store := keystore.NewMemoryStore()
+	sm := newTestStateManager(t, store)
+	other := NewStateManagerWithDB(zap.NewNop(), sm.DB)
+	other.SetBarrier(keystore.NewBarrier(store))
+	shares, _, err := sm.GenerateKeys(ctx, 3, 2)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if _, err = sm.UnlockVault(ctx, shares[0], ""); err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	// the threshold is reached in the state db but neither node holds
+	// enough shares to rebuild the master key
+	ready, err := other.UnlockVault(ctx, shares[1], "")
+	if !errors.Is(err, ErrSharesOnOtherNodes) {
+		t.Fatalf("UnlockVault: got %v, want %v", err, ErrSharesOnOtherNodes)
+	}
+	if ready || !sm.IsVaultLocked(ctx) {
+		t.Fatalf("vault unsealed without the shares on one node")
+	}
+	// submitting the first share again to the same node finishes the unseal
+	if ready, err = other.UnlockVault(ctx, shares[0], ""); err != nil || !ready {
+		t.Fatalf("UnlockVault on one node: %v, %v", ready, err)
+	}
+}
+
+// lostLockDB loses every lock right after it is acquired, like a holder
+// that stalled past VAULT_LOCK_TTL.
+type lostLockDB struct {
+	*MemoryDB
+}
+
+func (db lostLockDB) CheckLock(ctx context.Context, name string, token int64) (bool, error) {
+	return false, nil
+}
+
+func TestVaultLock(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	token, err := sm.DB.AcquireLock(ctx, VAULT_LOCK, time.Minute)
+	if err != nil || token == 0 {
+		t.Fatalf("AcquireLock: got %d, %v", token, err)
+	}
+	if _, _, err = sm.GenerateKeys(ctx, 3, 2); !errors.Is(err, ErrVaultBusy) {
+		t.Fatalf("GenerateKeys while locked: got %v, want %v", err, ErrVaultBusy)
+	}
+	if err = sm.DB.ReleaseLock(ctx, VAULT_LOCK, token); err != nil {
+		t.Fatalf("ReleaseLock: %v", err)
+	}
+	code, err := sm.GetInitCode(ctx)
+	if err != nil {
+		t.Fatalf("GetInitCode: %v", err)
+	}
+	if _, _, err = sm.InitVault(ctx, code, 3, 2); err != nil {
+		t.Fatalf("InitVault: %v", err)
+	}
+	// the lock is released after every operation
+	if _, _, err = sm.InitVault(ctx, code, 3, 2); !errors.Is(err, ErrVaultInitialized) {
+		t.Fatalf("second InitVault: got %v, want %v", err, ErrVaultInitialized)
+	}
+}
+
+func TestVaultLockLost(t *testing.T) {
+	ctx := context.Background()
+	sm := NewStateManagerWithDB(zap.NewNop(), lostLockDB{NewMemoryDB(zap.NewNop())})
+	sm.SetBarrier(keystore.NewBarrier(keystore.NewMemoryStore()))
+	if err := sm.InitStateDBCache(ctx); err != nil {
+		t.Fatalf("InitStateDBCache: %v", err)
+	}
+	if _, _, err := sm.GenerateKeys(ctx, 3, 2); !errors.Is(err, ErrVaultLockLost) {
+		t.Fatalf("got %v, want %v", err, ErrVaultLockLost)
+	}
+	if !sm.IsVaultDown(ctx) {
+		t.Fatalf("vault state changed after the lock was lost")
+	}
+}
+
+func TestLockPath(t *testing.T) {
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	unlock, err := sm.LockPath("app/db")
+	if err != nil {
+		t.Fatalf("LockPath: %v", err)
+	}
+	// other paths are not blocked
+	other, err := sm.LockPath("app/api")
+	if err != nil {
+		t.Fatalf("LockPath of another path: %v", err)
+	}
+	other()
+	unlock()
+	if unlock, err = sm.LockPath("app/db"); err != nil {
+		t.Fatalf("LockPath after release: %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,185 @@
+package statemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// VaultState is the state of the vault shared by every replica.
+type VaultState string
+
+const (
+	// not initialized yet
+	VAULT_STATE_DOWN VaultState = "down"
+	// keys are being generated by the node holding the vault lock
+	VAULT_STATE_INITIALIZING VaultState = "initializing"
+	// initialized and waiting for its first unseal
+	VAULT_STATE_LOCKED VaultState = "locked"
+	// sealed after being unsealed, or restored from a snapshot
+	VAULT_STATE_SEALED VaultState = "sealed"
+	VAULT_STATE_READY  VaultState = "ready"
+	// unsealed, serving reads but refusing writes
+	VAULT_STATE_MAINTENANCE VaultState = "maintenance"
+)
+
+// vaultTransitions lists the states each state can move to.
+var vaultTransitions = map[VaultState][]VaultState{
+	// down to sealed restores a snapshot into a new deployment
+	VAULT_STATE_DOWN:         {VAULT_STATE_INITIALIZING, VAULT_STATE_SEALED},
+	VAULT_STATE_INITIALIZING: {VAULT_STATE_LOCKED, VAULT_STATE_DOWN},
+	VAULT_STATE_LOCKED:       {VAULT_STATE_READY, VAULT_STATE_SEALED},
+	// sealed to sealed restores a snapshot over a sealed vault
+	VAULT_STATE_SEALED:      {VAULT_STATE_READY, VAULT_STATE_SEALED},
+	VAULT_STATE_READY:       {VAULT_STATE_SEALED, VAULT_STATE_MAINTENANCE},
+	VAULT_STATE_MAINTENANCE: {VAULT_STATE_READY, VAULT_STATE_SEALED},
+}
+
+// STATE_HISTORY_MAX is the number of transitions kept in the state history.
+const STATE_HISTORY_MAX = 1000
+
+var ErrIllegalTransition = errors.New("illegal vault state transition")
+
+// CanTransition reports whether the vault can move from s to next.
+func (s VaultState) CanTransition(next VaultState) bool {
+	for _, allowed := range vaultTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Sealed reports whether the vault is initialized and waits for unseal keys.
+func (s VaultState) Sealed() bool {
+	return s == VAULT_STATE_LOCKED || s == VAULT_STATE_SEALED
+}
+
+// Unsealed reports whether secrets can be read.
+func (s VaultState) Unsealed() bool {
+	return s == VAULT_STATE_READY || s == VAULT_STATE_MAINTENANCE
+}
+
+// StateTransition records a change of the vault state.
+type StateTransition struct {
+	Time   time.Time  `json:"time"`
+	From   VaultState `json:"from"`
+	To     VaultState `json:"to"`
+	Actor  string     `json:"actor"`
+	Reason string     `json:"reason"`
+	Node   string     `json:"node"`
+}
+
+type actorKey struct{}
+
+// WithActor attributes the state transitions made with ctx to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "system"
+}
+
+func (sm *StateManager) newTransition(ctx context.Context, from, to VaultState, reason string) *StateTransition {
+	return &StateTransition{
+		Time:   time.Now().UTC(),
+		From:   from,
+		To:     to,
+		Actor:  actorFrom(ctx),
+		Reason: reason,
+		Node:   sm.node,
+	}
+}
+
+// transition moves the vault to the given state and records it in the state
+// history. Transitions the state machine does not allow fail with
+// ErrIllegalTransition, and the write fails with ErrVaultLockLost unless
+// lock is still held.
+func (sm *StateManager) transition(ctx context.Context, lock *vaultLock, to VaultState, reason string) error {
+	from, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	if !from.CanTransition(to) {
+		sm.logger.Warn("illegal vault state transition",
+			zap.String("from", string(from)),
+			zap.String("to", string(to)),
+			zap.String("reason", reason))
+		return fmt.Errorf("%w from %s to %s", ErrIllegalTransition, from, to)
+	}
+	t := sm.newTransition(ctx, from, to, reason)
+	err = sm.DB.SetVaultState(ctx, t, lock.token)
+	if errors.Is(err, ErrVaultLockLost) {
+		sm.logger.Warn("vault lock lost", zap.Int64("token", lock.token), zap.String("node", sm.node))
+		return err
+	} else if err != nil {
+		sm.logger.Error("error setting vault state", zap.Error(err))
+		return err
+	}
+	sm.logStateChange(t)
+	return nil
+}
+
+// logStateChange logs every change of the vault state.
+func (sm *StateManager) logStateChange(t *StateTransition) {
+	sm.logger.Info("vault state changed",
+		zap.String("from", string(t.From)),
+		zap.String("to", string(t.To)),
+		zap.String("actor", t.Actor),
+		zap.String("reason", t.Reason),
+		zap.String("node", t.Node))
+}
+
+// VaultState returns the current state of the vault.
+func (sm *StateManager) VaultState(ctx context.Context) (VaultState, error) {
+	return sm.DB.GetVaultState(ctx)
+}
+
+// StateHistory returns up to limit of the latest transitions, newest first.
+func (sm *StateManager) StateHistory(ctx context.Context, limit int) ([]*StateTransition, error) {
+	if limit < 1 || limit > STATE_HISTORY_MAX {
+		limit = STATE_HISTORY_MAX
+	}
+	return sm.DB.GetStateHistory(ctx, limit)
+}
+
+// SetMaintenance freezes or unfreezes writes to the keystore. Only a ready
+// vault can enter maintenance, and it leaves maintenance ready.
+func (sm *StateManager) SetMaintenance(ctx context.Context, enabled bool) error {
+	lock, err := sm.lockVault(ctx, VAULT_LOCK_WAIT)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	to, reason := VAULT_STATE_READY, "maintenance disabled"
+	if enabled {
+		to, reason = VAULT_STATE_MAINTENANCE, "maintenance enabled"
+	}
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		sm.logger.Error("error getting vault state", zap.Error(err))
+		return err
+	}
+	if enabled == (state == VAULT_STATE_MAINTENANCE) {
+		return nil
+	}
+	return sm.transition(ctx, lock, to, reason)
+}
+
+// IsInMaintenance reports whether an operator has frozen writes to the
+// keystore.
+func (sm *StateManager) IsInMaintenance(ctx context.Context) (bool, error) {
+	state, err := sm.DB.GetVaultState(ctx)
+	if err != nil {
+		return false, err
+	}
+	return state == VAULT_STATE_MAINTENANCE, nil
+}
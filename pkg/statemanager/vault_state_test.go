@@ -0,0 +1,101 @@
+package statemanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/skriptvalley/keyhouse/pkg/keystore"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from VaultState
+		to   VaultState
+		want bool
+	}{
+		{VAULT_STATE_DOWN, VAULT_STATE_INITIALIZING, true},
+		{VAULT_STATE_DOWN, VAULT_STATE_SEALED, true},
+		{VAULT_STATE_DOWN, VAULT_STATE_READY, false},
+		{VAULT_STATE_INITIALIZING, VAULT_STATE_LOCKED, true},
+		{VAULT_STATE_INITIALIZING, VAULT_STATE_DOWN, true},
+		{VAULT_STATE_LOCKED, VAULT_STATE_READY, true},
+		{VAULT_STATE_LOCKED, VAULT_STATE_MAINTENANCE, false},
+		{VAULT_STATE_SEALED, VAULT_STATE_SEALED, true},
+		{VAULT_STATE_SEALED, VAULT_STATE_DOWN, false},
+		{VAULT_STATE_READY, VAULT_STATE_MAINTENANCE, true},
+		{VAULT_STATE_READY, VAULT_STATE_LOCKED, false},
+		{VAULT_STATE_MAINTENANCE, VAULT_STATE_READY, true},
+		{VAULT_STATE_MAINTENANCE, VAULT_STATE_SEALED, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"-"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransition(tt.to); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateHistory(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	shares, _, err := sm.GenerateKeys(WithActor(ctx, "operator"), 1, 1)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	unlock(t, sm, shares)
+	if err = sm.SealVault(WithActor(ctx, "10.0.0.1"), "test"); err != nil {
+		t.Fatalf("SealVault: %v", err)
+	}
+	history, err := sm.StateHistory(ctx, 0)
+	if err != nil {
+		t.Fatalf("StateHistory: %v", err)
+	}
+	want := []struct {
+		from, to VaultState
+		actor    string
+	}{
+		{VAULT_STATE_READY, VAULT_STATE_SEALED, "10.0.0.1"},
+		{VAULT_STATE_LOCKED, VAULT_STATE_READY, "system"},
+		{VAULT_STATE_INITIALIZING, VAULT_STATE_LOCKED, "operator"},
+		{VAULT_STATE_DOWN, VAULT_STATE_INITIALIZING, "operator"},
+		{"", VAULT_STATE_DOWN, "system"},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(history), len(want))
+	}
+	for i, w := range want {
+		got := history[i]
+		if got.From != w.from || got.To != w.to || got.Actor != w.actor || got.Node != sm.NodeID() {
+			t.Fatalf("transition %d: got %+v, want %+v", i, got, w)
+		}
+	}
+	if limited, _ := sm.StateHistory(ctx, 1); len(limited) != 1 {
+		t.Fatalf("got %d transitions with a limit of 1", len(limited))
+	}
+}
+
+func TestSetMaintenance(t *testing.T) {
+	ctx := context.Background()
+	sm := newTestStateManager(t, keystore.NewMemoryStore())
+	shares, _, err := sm.GenerateKeys(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("GenerateKeys: %v", err)
+	}
+	if err = sm.SetMaintenance(ctx, true); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("maintenance while sealed: got %v, want %v", err, ErrIllegalTransition)
+	}
+	unlock(t, sm, shares)
+	for _, enabled := range []bool{true, true, false, false} {
+		if err = sm.SetMaintenance(ctx, enabled); err != nil {
+			t.Fatalf("SetMaintenance(%v): %v", enabled, err)
+		}
+		if maintenance, _ := sm.IsInMaintenance(ctx); maintenance != enabled {
+			t.Fatalf("IsInMaintenance: got %v, want %v", maintenance, enabled)
+		}
+		if !sm.IsVaultReady(ctx) {
+			t.Fatalf("secrets cannot be read with maintenance %v", enabled)
+		}
+	}
+}